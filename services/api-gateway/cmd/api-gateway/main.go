@@ -3,6 +3,9 @@ package main
 import (
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -10,6 +13,9 @@ import (
 	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/client"
 	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/handlers"
 	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/middleware"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience/admin"
+	resiliencemw "github.com/LuoZihYuan/go-down/services/pkg/resilience/middleware"
 )
 
 // @title API Gateway
@@ -17,6 +23,10 @@ import (
 // @description API Gateway with resilience patterns
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Admin token as "Bearer <ADMIN_TOKEN>"
 
 func main() {
 	// Get order service URL from environment
@@ -25,14 +35,90 @@ func main() {
 		log.Fatal("ORDER_SERVICE_URL environment variable is required")
 	}
 
+	// Load circuit breaker settings from config file and environment
+	orderBreakerConfig, err := resilience.LoadCircuitBreakerConfig("order", resilience.DefaultCircuitBreakerConfig())
+	if err != nil {
+		log.Fatalf("Invalid circuit breaker configuration: %v", err)
+	}
+
+	// Load retry settings the same way
+	orderRetryConfig, err := resilience.LoadRetryConfig("order", resilience.DefaultRetryConfig())
+	if err != nil {
+		log.Fatalf("Invalid retry configuration: %v", err)
+	}
+
+	// Hedging of order lookups is off unless enabled, e.g. HEDGE_ORDER_ENABLED=true
+	orderHedgeConfig, err := resilience.LoadHedgeConfig("order", resilience.DefaultHedgeConfig())
+	if err != nil {
+		log.Fatalf("Invalid hedge configuration: %v", err)
+	}
+
+	// Order lookups are cached and served stale while the order service is unavailable
+	orderCacheConfig, err := resilience.LoadCacheConfig("order", resilience.DefaultCacheConfig())
+	if err != nil {
+		log.Fatalf("Invalid cache configuration: %v", err)
+	}
+
+	// Responses to order creation are kept per Idempotency-Key so retries are safe
+	orderIdempotencyConfig, err := resilience.LoadIdempotencyConfig("orders", resilience.DefaultIdempotencyConfig())
+	if err != nil {
+		log.Fatalf("Invalid idempotency configuration: %v", err)
+	}
+	orderIdempotency := resilience.NewIdempotencyStore("orders", orderIdempotencyConfig.TTL)
+
+	// Every request gets a 5s end-to-end deadline, propagated to downstream services
+	deadlineDefaults := resilience.DefaultDeadlineConfig()
+	deadlineDefaults.Timeout = 5 * time.Second
+	deadlineConfig, err := resilience.LoadDeadlineConfig("api-gateway", deadlineDefaults)
+	if err != nil {
+		log.Fatalf("Invalid deadline configuration: %v", err)
+	}
+
+	// Clients are rate limited per route; limits are reloaded on SIGHUP or through the admin API
+	rateLimitDefaults := resilience.RateLimitConfig{Routes: map[string]resilience.RateLimitRule{
+		"POST /api/orders":    {Rate: 10, Burst: 20, Key: resilience.RateLimitKeyCustomerID},
+		"GET /api/orders/:id": {Rate: 50, Burst: 100, Key: resilience.RateLimitKeyIP},
+	}}
+	rateLimitConfig, err := resilience.LoadRateLimitConfig(rateLimitDefaults)
+	if err != nil {
+		log.Fatalf("Invalid rate limit configuration: %v", err)
+	}
+	rateLimits := resilience.NewRateLimits(rateLimitConfig)
+	reloadRateLimits := func() error {
+		cfg, err := resilience.LoadRateLimitConfig(rateLimitDefaults)
+		if err != nil {
+			return err
+		}
+		rateLimits.Update(cfg)
+		return nil
+	}
+	go func() {
+		hangup := make(chan os.Signal, 1)
+		signal.Notify(hangup, syscall.SIGHUP)
+		for range hangup {
+			if err := reloadRateLimits(); err != nil {
+				log.Printf("Keeping current rate limits, reload failed: %v", err)
+				continue
+			}
+			log.Println("Rate limits reloaded")
+		}
+	}()
+
 	// Setup router
 	router := gin.New()
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 	router.Use(middleware.MetricsMiddleware())
+	router.Use(middleware.RateLimitMiddleware(rateLimits))
+	router.Use(resiliencemw.DeadlineMiddleware(deadlineConfig, middleware.RenderError))
 
 	// Initialize clients
-	orderClient := client.NewOrderClient(orderServiceURL)
+	orderClient := client.NewOrderClient(orderServiceURL, client.OrderClientConfig{
+		CircuitBreaker: orderBreakerConfig,
+		Retry:          orderRetryConfig,
+		Hedge:          orderHedgeConfig,
+		Cache:          orderCacheConfig,
+	})
 
 	// Root group
 	rootHandler := handlers.NewRootHandler()
@@ -46,10 +132,25 @@ func main() {
 	orderHandler := handlers.NewOrderHandler(orderClient)
 	api := router.Group("/api")
 	{
-		api.POST("/orders", orderHandler.CreateOrder)
+		// Orders without a client key get one, reused by every retry to the order service
+		api.POST("/orders", resiliencemw.IdempotencyMiddleware(orderIdempotency, middleware.RenderError), resiliencemw.IdempotencyKeyMiddleware(), orderHandler.CreateOrder)
 		api.GET("/orders/:id", orderHandler.GetOrder)
 	}
 
+	// Admin group, served only with an admin token to guard it
+	if adminToken := os.Getenv(admin.TokenEnv); adminToken != "" {
+		adminGroup := router.Group("/admin/resilience", admin.TokenMiddleware(adminToken))
+		admin.NewHandler(resilience.DefaultRegistry).Register(adminGroup)
+
+		rateLimitHandler := handlers.NewRateLimitHandler(rateLimits, reloadRateLimits)
+		{
+			adminGroup.GET("/rate-limits", rateLimitHandler.GetRateLimits)
+			adminGroup.POST("/rate-limits/reload", rateLimitHandler.ReloadRateLimits)
+		}
+	} else {
+		log.Printf("Admin API disabled, set %s to enable it", admin.TokenEnv)
+	}
+
 	// Swagger group (conditionally registered based on build tags)
 	registerSwagger(router)
 
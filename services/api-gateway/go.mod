@@ -3,6 +3,7 @@ module github.com/LuoZihYuan/go-down/services/api-gateway
 go 1.25.1
 
 require (
+	github.com/LuoZihYuan/go-down/services/pkg/resilience v0.0.0-00010101000000-000000000000
 	github.com/gin-gonic/gin v1.11.0
 	github.com/prometheus/client_golang v1.19.1
 	github.com/swaggo/files v1.0.1
@@ -60,3 +61,5 @@ require (
 	golang.org/x/tools v0.38.0 // indirect
 	google.golang.org/protobuf v1.36.9 // indirect
 )
+
+replace github.com/LuoZihYuan/go-down/services/pkg/resilience => ../pkg/resilience
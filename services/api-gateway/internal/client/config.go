@@ -0,0 +1,14 @@
+package client
+
+import "github.com/LuoZihYuan/go-down/services/pkg/resilience"
+
+// OrderClientConfig tunes the resilience policies guarding the order service
+// The stage client accepts it for parity and ignores it
+type OrderClientConfig struct {
+	CircuitBreaker resilience.CircuitBreakerConfig
+	Retry          resilience.RetryConfig
+	// Hedge applies to order lookups only, as they are read-only
+	Hedge resilience.HedgeConfig
+	// Cache keeps order lookups to serve while the order service is unavailable
+	Cache resilience.CacheConfig
+}
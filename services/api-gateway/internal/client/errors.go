@@ -0,0 +1,6 @@
+package client
+
+import "errors"
+
+// ErrOrderNotFound is returned when the order service has no order with the requested ID
+var ErrOrderNotFound = errors.New("order not found")
@@ -12,40 +12,96 @@ import (
 	"time"
 
 	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/models"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
 )
 
 // OrderClient handles communication with the order service
-// Resilient version: Includes timeout and circuit breaker
+// Resilient version: Includes timeout, retry, circuit breaker, hedged and coalesced
+// lookups, and a cache serving stale orders while the order service is unavailable
 type OrderClient struct {
 	httpClient     *http.Client
 	baseURL        string
-	circuitBreaker *CircuitBreaker[*models.OrderResponse]
+	circuitBreaker *resilience.CircuitBreaker[*models.OrderResponse]
+	retry          *resilience.Retry
+	hedge          *resilience.Hedge[*models.OrderResponse]
+	cache          *resilience.Cache[*models.OrderResponse]
+	coalescer      *resilience.Coalescer[*models.OrderResponse]
 }
 
 // NewOrderClient creates a new resilient order client
-func NewOrderClient(baseURL string) *OrderClient {
+// config tunes the retry, circuit breaker, hedging and cache guarding the order service
+func NewOrderClient(baseURL string, config OrderClientConfig) *OrderClient {
 	return &OrderClient{
 		httpClient: &http.Client{
 			Timeout: 5 * time.Second, // 5s timeout for API Gateway
 		},
 		baseURL: baseURL,
-		// Circuit breaker: 5 failures in 10 seconds opens circuit for 30 seconds
-		circuitBreaker: NewCircuitBreaker[*models.OrderResponse]("order", 5, 30*time.Second),
+		// Circuit breaker: by default 5 failures in 10 seconds opens circuit for 30 seconds
+		circuitBreaker: resilience.NewCircuitBreaker[*models.OrderResponse]("order", resilience.WithConfig(config.CircuitBreaker)),
+		// Retry: by default 3 attempts with jittered backoff, capped by a budget of 10% of calls
+		retry: resilience.NewRetry("order", resilience.WithRetryConfig(config.Retry)),
+		// Hedge: when enabled, lookups slower than the observed p95 are sent a second time
+		hedge: resilience.NewHedge[*models.OrderResponse]("order", resilience.WithHedgeConfig(config.Hedge)),
+		// Cache: lookups are served for 5s by default, and for 10 minutes more if the order service fails
+		cache: resilience.NewCache[*models.OrderResponse]("order", resilience.WithCacheConfig(config.Cache)),
+		// Coalescer: concurrent lookups of the same order share one upstream call
+		coalescer: resilience.NewCoalescer[*models.OrderResponse]("order"),
 	}
 }
 
 // CreateOrder sends an order creation request to the order service with resilience patterns
+// Without an idempotency key in ctx a repeated POST could create a second order,
+// so it is only retried when the order service was never reached
 func (c *OrderClient) CreateOrder(ctx context.Context, req *models.OrderRequest) (*models.OrderResponse, error) {
-	// Execute with circuit breaker protection
-	return c.circuitBreaker.Execute(func() (*models.OrderResponse, error) {
-		return c.makeCreateOrderCall(ctx, req)
+	var result *models.OrderResponse
+
+	retry := resilience.Policy(c.retry)
+	if _, ok := resilience.IdempotencyKeyFromContext(ctx); !ok {
+		retry = c.retry.UnsentOnly()
+	}
+
+	// Retry outside the circuit breaker so every attempt is counted
+	// and an open circuit ends the retries
+	err := retry.Do(ctx, func() error {
+		var callErr error
+		result, callErr = c.circuitBreaker.Execute(func() (*models.OrderResponse, error) {
+			return c.makeCreateOrderCall(ctx, req)
+		})
+		return callErr
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
-// GetOrder retrieves an order by ID from the order service
-// Note: GET requests don't go through circuit breaker as they're read-only
-func (c *OrderClient) GetOrder(ctx context.Context, orderID string) (*models.OrderResponse, error) {
-	return c.makeGetOrderCall(ctx, orderID)
+// GetOrder retrieves an order by ID, from the cache while fresh and otherwise from the order service
+// Concurrent lookups of the same order are coalesced into one upstream call, which
+// shares the circuit breaker of order creation so an open circuit stops it too,
+// is retried and, being safe to repeat, may be hedged
+// When the order service fails a previously seen order is served stale, as reported by the cache status
+func (c *OrderClient) GetOrder(ctx context.Context, orderID string) (*models.OrderResponse, resilience.CacheStatus, error) {
+	return c.cache.Fetch(ctx, orderID, func(ctx context.Context) (*models.OrderResponse, error) {
+		return c.coalescer.Do(ctx, orderID, func(ctx context.Context) (*models.OrderResponse, error) {
+			var result *models.OrderResponse
+
+			err := c.retry.Do(ctx, func() error {
+				var callErr error
+				result, callErr = c.circuitBreaker.Execute(func() (*models.OrderResponse, error) {
+					return c.hedge.Execute(ctx, func(ctx context.Context) (*models.OrderResponse, error) {
+						return c.makeGetOrderCall(ctx, orderID)
+					})
+				})
+				return callErr
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return result, nil
+		})
+	})
 }
 
 // makeCreateOrderCall performs the actual HTTP POST call
@@ -62,18 +118,27 @@ func (c *OrderClient) makeCreateOrderCall(ctx context.Context, req *models.Order
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	if key, ok := resilience.IdempotencyKeyFromContext(ctx); ok {
+		httpReq.Header.Set(resilience.IdempotencyKeyHeader, key)
+	}
+
+	// Orders are critical so order-service sheds lookups before them
+	httpReq.Header.Set(resilience.PriorityHeader, resilience.PriorityCritical.String())
+	resilience.SetDeadlineHeader(httpReq)
 
-	// Send request (with 5s timeout from httpClient)
+	// Send request (with 5s timeout from httpClient, or less as set by the request deadline)
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, resilience.NewTransportError("order", fmt.Errorf("failed to send request: %w", err))
 	}
 	defer resp.Body.Close()
 
 	// Check status code
-	if resp.StatusCode != http.StatusOK {
+	// 4xx responses are classified as client errors and don't trip the circuit breaker
+	// 202 means the order was accepted with its payment pending
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("order service returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, resilience.NewStatusError("order", resp.StatusCode, string(bodyBytes))
 	}
 
 	// Parse response
@@ -93,20 +158,24 @@ func (c *OrderClient) makeGetOrderCall(ctx context.Context, orderID string) (*mo
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	httpReq.Header.Set(resilience.PriorityHeader, resilience.PrioritySheddable.String())
+	resilience.SetDeadlineHeader(httpReq)
+
 	// Send request
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, resilience.NewTransportError("order", fmt.Errorf("failed to send request: %w", err))
 	}
 	defer resp.Body.Close()
 
 	// Check status code
+	// A missing order is a client error so it neither trips the circuit breaker nor is served stale
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("order not found")
+		return nil, &resilience.UpstreamError{Service: "order", StatusCode: resp.StatusCode, Err: ErrOrderNotFound}
 	}
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("order service returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, resilience.NewStatusError("order", resp.StatusCode, string(bodyBytes))
 	}
 
 	// Parse response
@@ -11,6 +11,7 @@ import (
 	"net/http"
 
 	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/models"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
 )
 
 // OrderClient handles communication with the order service
@@ -21,7 +22,8 @@ type OrderClient struct {
 }
 
 // NewOrderClient creates a new order client
-func NewOrderClient(baseURL string) *OrderClient {
+// config is accepted for parity with the resilient client and ignored
+func NewOrderClient(baseURL string, config OrderClientConfig) *OrderClient {
 	return &OrderClient{
 		httpClient: &http.Client{
 			// No timeout in stage - allows full cascade failure
@@ -53,9 +55,9 @@ func (c *OrderClient) CreateOrder(ctx context.Context, req *models.OrderRequest)
 	defer resp.Body.Close()
 
 	// Check status code
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("order service returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, resilience.NewStatusError("order", resp.StatusCode, string(bodyBytes))
 	}
 
 	// Parse response
@@ -68,34 +70,35 @@ func (c *OrderClient) CreateOrder(ctx context.Context, req *models.OrderRequest)
 }
 
 // GetOrder retrieves an order by ID from the order service
-func (c *OrderClient) GetOrder(ctx context.Context, orderID string) (*models.OrderResponse, error) {
+// The cache status is always empty as nothing is cached
+func (c *OrderClient) GetOrder(ctx context.Context, orderID string) (*models.OrderResponse, resilience.CacheStatus, error) {
 	// Create HTTP request
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/orders/"+orderID, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Send request
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, "", fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Check status code
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("order not found")
+		return nil, "", ErrOrderNotFound
 	}
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("order service returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, "", fmt.Errorf("order service returned status %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	// Parse response
 	var orderResp models.OrderResponse
 	if err := json.NewDecoder(resp.Body).Decode(&orderResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &orderResp, nil
+	return &orderResp, "", nil
 }
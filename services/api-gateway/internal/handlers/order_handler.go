@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -8,6 +10,7 @@ import (
 
 	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/client"
 	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/models"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
 )
 
 // OrderHandler handles order-related requests by proxying to order service
@@ -29,10 +32,15 @@ func NewOrderHandler(orderClient *client.OrderClient) *OrderHandler {
 // @Accept json
 // @Produce json
 // @Param order body models.OrderRequest true "Order request"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe; repeats replay the first response"
 // @Success 200 {object} models.OrderResponse
+// @Success 202 {object} models.OrderResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Failure 503 {object} models.ErrorResponse
+// @Failure 504 {object} models.ErrorResponse
 // @Router /api/orders [post]
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var req models.OrderRequest
@@ -49,7 +57,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	order, err := h.orderClient.CreateOrder(c.Request.Context(), &req)
 	if err != nil {
 		// Handle circuit breaker error
-		if err == client.ErrCircuitOpen {
+		if errors.Is(err, resilience.ErrCircuitOpen) {
 			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
 				Title:  "Service Unavailable",
 				Status: http.StatusServiceUnavailable,
@@ -58,6 +66,28 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 			return
 		}
 
+		// Handle the end-to-end deadline running out
+		if errors.Is(err, context.DeadlineExceeded) {
+			c.JSON(http.StatusGatewayTimeout, models.ErrorResponse{
+				Title:  "Gateway Timeout",
+				Status: http.StatusGatewayTimeout,
+				Detail: "Order service did not respond within the request deadline",
+			})
+			return
+		}
+
+		// Pass on the order service rejecting the request itself, such as 409 for a reused Idempotency-Key,
+		// so callers can tell their own mistakes from server failures
+		var upstreamErr *resilience.UpstreamError
+		if errors.As(err, &upstreamErr) && upstreamErr.IsClientError() {
+			c.JSON(upstreamErr.StatusCode, models.ErrorResponse{
+				Title:  http.StatusText(upstreamErr.StatusCode),
+				Status: upstreamErr.StatusCode,
+				Detail: fmt.Sprintf("Order service rejected the order: %v", err),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Title:  "Internal Server Error",
 			Status: http.StatusInternalServerError,
@@ -66,27 +96,36 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	// Orders accepted while payment is unavailable keep the 202 of the order service
+	if order.Status == models.OrderStatusPendingPayment {
+		c.JSON(http.StatusAccepted, order)
+		return
+	}
 	c.JSON(http.StatusOK, order)
 }
 
 // GetOrder proxies order retrieval to the order service
 // @Summary Get order
 // @Description Retrieves an order by ID via order service
+// @Description While the order service is unavailable, previously seen orders are served stale with X-Cache: STALE
 // @Tags Orders
 // @Produce json
 // @Param id path string true "Order ID"
 // @Success 200 {object} models.OrderResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Failure 504 {object} models.ErrorResponse
 // @Router /api/orders/{id} [get]
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	orderID := c.Param("id")
 
 	// Proxy to order service
-	order, err := h.orderClient.GetOrder(c.Request.Context(), orderID)
+	order, cacheStatus, err := h.orderClient.GetOrder(c.Request.Context(), orderID)
 	if err != nil {
 		// Check if order not found
-		if err.Error() == "order not found" {
+		if errors.Is(err, client.ErrOrderNotFound) {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
 				Title:  "Not Found",
 				Status: http.StatusNotFound,
@@ -95,6 +134,26 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 			return
 		}
 
+		// Handle circuit breaker error, reached only for orders not cached
+		if errors.Is(err, resilience.ErrCircuitOpen) {
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Title:  "Service Unavailable",
+				Status: http.StatusServiceUnavailable,
+				Detail: "Order service is temporarily unavailable (circuit breaker open)",
+			})
+			return
+		}
+
+		// Handle the end-to-end deadline running out
+		if errors.Is(err, context.DeadlineExceeded) {
+			c.JSON(http.StatusGatewayTimeout, models.ErrorResponse{
+				Title:  "Gateway Timeout",
+				Status: http.StatusGatewayTimeout,
+				Detail: "Order service did not respond within the request deadline",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Title:  "Internal Server Error",
 			Status: http.StatusInternalServerError,
@@ -103,5 +162,11 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
+	if cacheStatus != "" {
+		c.Header(resilience.CacheHeader, string(cacheStatus))
+	}
+	if cacheStatus == resilience.CacheStale {
+		c.Header("Warning", `110 - "Response is Stale"`)
+	}
 	c.JSON(http.StatusOK, order)
 }
@@ -0,0 +1,136 @@
+//go:build !stage
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/client"
+	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/middleware"
+	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/models"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+	resiliencemw "github.com/LuoZihYuan/go-down/services/pkg/resilience/middleware"
+)
+
+// orderService fakes the order service, creating one order per idempotency key
+// like the real one, and failing the first attempt after the order was created
+type orderService struct {
+	mu       sync.Mutex
+	attempts int
+	orders   map[string]models.OrderResponse
+}
+
+func (s *orderService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts++
+	key := r.Header.Get(resilience.IdempotencyKeyHeader)
+	if key == "" {
+		// Without a key every attempt is a new order
+		key = fmt.Sprintf("unkeyed-%d", s.attempts)
+	}
+	order := models.OrderResponse{OrderID: "order-" + key, Status: "completed"}
+	s.orders[key] = order
+
+	if s.attempts == 1 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	json.NewEncoder(w).Encode(order)
+}
+
+func TestCreateOrderRetryCreatesOneOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := &orderService{orders: make(map[string]models.OrderResponse)}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	retry := resilience.DefaultRetryConfig()
+	retry.BaseBackoff, retry.MaxBackoff = time.Millisecond, time.Millisecond
+	orderClient := client.NewOrderClient(server.URL, client.OrderClientConfig{
+		CircuitBreaker: resilience.DefaultCircuitBreakerConfig(),
+		Retry:          retry,
+		Hedge:          resilience.DefaultHedgeConfig(),
+		Cache:          resilience.DefaultCacheConfig(),
+	})
+	handler := NewOrderHandler(orderClient)
+
+	router := gin.New()
+	router.POST("/api/orders",
+		resiliencemw.IdempotencyMiddleware(resilience.NewIdempotencyStore("test-gateway-orders", time.Minute), middleware.RenderError),
+		resiliencemw.IdempotencyKeyMiddleware(),
+		handler.CreateOrder)
+
+	// The client sends no Idempotency-Key, the gateway mints one for the retries
+	req := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(`{"customer_id":"c1","amount":10,"items":[{"product_id":"p1","quantity":1,"price":10}]}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s), want 200", w.Code, w.Body.String())
+	}
+	if upstream.attempts != 2 {
+		t.Fatalf("attempts = %d, want 2", upstream.attempts)
+	}
+	if len(upstream.orders) != 1 {
+		t.Fatalf("orders created = %d, want 1", len(upstream.orders))
+	}
+}
+
+func TestCreateOrderPassesClientErrorsThrough(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		upstream int
+		want     int
+	}{
+		{"bad request", http.StatusBadRequest, http.StatusBadRequest},
+		{"idempotency conflict", http.StatusConflict, http.StatusConflict},
+		{"unprocessable", http.StatusUnprocessableEntity, http.StatusUnprocessableEntity},
+		{"server error", http.StatusInternalServerError, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.upstream)
+				json.NewEncoder(w).Encode(models.ErrorResponse{Status: tt.upstream, Detail: "rejected by order service"})
+			}))
+			defer server.Close()
+
+			retry := resilience.DefaultRetryConfig()
+			retry.BaseBackoff, retry.MaxBackoff = time.Millisecond, time.Millisecond
+			handler := NewOrderHandler(client.NewOrderClient(server.URL, client.OrderClientConfig{
+				CircuitBreaker: resilience.DefaultCircuitBreakerConfig(),
+				Retry:          retry,
+				Hedge:          resilience.DefaultHedgeConfig(),
+				Cache:          resilience.DefaultCacheConfig(),
+			}))
+			router := gin.New()
+			router.POST("/api/orders", handler.CreateOrder)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(`{"customer_id":"c1","amount":10,"items":[{"product_id":"p1","quantity":1,"price":10}]}`))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var resp models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("body %q is not an error response: %v", w.Body.String(), err)
+			}
+			if w.Code != tt.want || resp.Status != tt.want {
+				t.Fatalf("status = %d (body status %d), want %d", w.Code, resp.Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/models"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+// RateLimitHandler exposes the gateway's rate limits and reloads them without a restart
+type RateLimitHandler struct {
+	limits *resilience.RateLimits
+	reload func() error
+}
+
+// NewRateLimitHandler creates a new rate limit handler
+// reload re-reads the configuration and applies it to limits
+func NewRateLimitHandler(limits *resilience.RateLimits, reload func() error) *RateLimitHandler {
+	return &RateLimitHandler{
+		limits: limits,
+		reload: reload,
+	}
+}
+
+// GetRateLimits lists the rate limit of every limited route
+// @Summary Get rate limits
+// @Description Lists the rate limit applied to every limited route
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {array} models.RateLimitRuleStatus
+// @Failure 401 {object} models.ErrorResponse
+// @Router /admin/resilience/rate-limits [get]
+func (h *RateLimitHandler) GetRateLimits(c *gin.Context) {
+	c.JSON(http.StatusOK, h.rules())
+}
+
+// ReloadRateLimits re-reads the rate limit configuration
+// @Summary Reload rate limits
+// @Description Re-reads the rate limits from the config file; routes with unchanged limits keep their state
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {array} models.RateLimitRuleStatus
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /admin/resilience/rate-limits/reload [post]
+func (h *RateLimitHandler) ReloadRateLimits(c *gin.Context) {
+	if err := h.reload(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Title:  "Bad Request",
+			Status: http.StatusBadRequest,
+			Detail: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.rules())
+}
+
+// rules converts the current rate limits to their API model, sorted by route
+func (h *RateLimitHandler) rules() []models.RateLimitRuleStatus {
+	rules := make([]models.RateLimitRuleStatus, 0)
+	for route, rule := range h.limits.Rules() {
+		rules = append(rules, models.RateLimitRuleStatus{
+			Route: route,
+			Rate:  rule.Rate,
+			Burst: rule.Burst,
+			Key:   rule.Key,
+		})
+	}
+	slices.SortFunc(rules, func(a, b models.RateLimitRuleStatus) int {
+		return strings.Compare(a.Route, b.Route)
+	})
+	return rules
+}
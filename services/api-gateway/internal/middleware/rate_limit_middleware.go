@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/api-gateway/internal/models"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+const (
+	// APIKeyHeader identifies the client when a route is limited per API key
+	APIKeyHeader = "X-API-Key"
+	// CustomerIDHeader identifies the customer when a route is limited per customer
+	CustomerIDHeader = "X-Customer-ID"
+)
+
+// RateLimitMiddleware throttles clients that exceed the limit of the matched route
+// Routes are named "<METHOD> <path pattern>", e.g. "GET /api/orders/:id"
+// Clients are identified by the key of the route's rule, falling back to their IP
+// Every limited response carries RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset,
+// throttled requests get 429 with Retry-After
+func RateLimitMiddleware(limits *resilience.RateLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter, ok := limits.Limiter(c.Request.Method + " " + c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		decision := limiter.Allow(rateLimitKey(c, limiter.Rule().Key))
+		c.Header("RateLimit-Limit", strconv.Itoa(decision.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(decision.Reset))
+
+		if !decision.Allowed {
+			c.Header("Retry-After", ceilSeconds(decision.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, models.ErrorResponse{
+				Title:  "Too Many Requests",
+				Status: http.StatusTooManyRequests,
+				Detail: "Rate limit exceeded, retry after " + ceilSeconds(decision.RetryAfter) + " seconds",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey identifies the client a request is counted against
+func rateLimitKey(c *gin.Context, kind string) string {
+	switch kind {
+	case resilience.RateLimitKeyAPIKey:
+		if key := c.GetHeader(APIKeyHeader); key != "" {
+			return "api_key:" + key
+		}
+	case resilience.RateLimitKeyCustomerID:
+		if id := customerID(c); id != "" {
+			return "customer_id:" + id
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// customerID reads the customer from the query, the X-Customer-ID header or a JSON body
+// The body is restored so handlers can still bind it
+func customerID(c *gin.Context) string {
+	if id := c.Query("customer_id"); id != "" {
+		return id
+	}
+	if id := c.GetHeader(CustomerIDHeader); id != "" {
+		return id
+	}
+	if c.Request.Body == nil || c.ContentType() != gin.MIMEJSON {
+		return ""
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var payload struct {
+		CustomerID string `json:"customer_id"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return ""
+	}
+	return payload.CustomerID
+}
+
+// ceilSeconds formats d as whole seconds, rounded up
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
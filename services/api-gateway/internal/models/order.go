@@ -18,13 +18,16 @@ type Item struct {
 	Price     float64 `json:"price" binding:"required,gt=0" example:"49.99"`
 } // @name Item
 
+// OrderStatusPendingPayment is an order accepted while its payment could not be made yet
+const OrderStatusPendingPayment = "pending_payment"
+
 // OrderResponse represents an order processing result
 // @Description Order processing response
 type OrderResponse struct {
 	OrderID    string    `json:"order_id" example:"order-abc123"`
 	CustomerID string    `json:"customer_id" example:"cust-123"`
 	Amount     float64   `json:"amount" example:"99.99"`
-	Status     string    `json:"status" example:"completed"`
+	Status     string    `json:"status" example:"completed" enums:"completed,pending_payment,payment_failed"`
 	PaymentID  string    `json:"payment_id" example:"pay-xyz789"`
 	Items      []Item    `json:"items"`
 	CreatedAt  time.Time `json:"created_at" example:"2025-01-15T10:30:00Z"`
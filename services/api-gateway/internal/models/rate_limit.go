@@ -0,0 +1,10 @@
+package models
+
+// RateLimitRuleStatus represents the rate limit applied to a route
+// @Description Rate limit of a route
+type RateLimitRuleStatus struct {
+	Route string  `json:"route" example:"POST /api/orders"`
+	Rate  float64 `json:"rate" example:"10"`
+	Burst int     `json:"burst" example:"20"`
+	Key   string  `json:"key" example:"customer_id"`
+} // @name RateLimitRuleStatus
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -10,6 +12,11 @@ import (
 	"github.com/LuoZihYuan/go-down/services/order-service/internal/client"
 	"github.com/LuoZihYuan/go-down/services/order-service/internal/handlers"
 	"github.com/LuoZihYuan/go-down/services/order-service/internal/middleware"
+	"github.com/LuoZihYuan/go-down/services/order-service/internal/store"
+	"github.com/LuoZihYuan/go-down/services/order-service/internal/worker"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience/admin"
+	resiliencemw "github.com/LuoZihYuan/go-down/services/pkg/resilience/middleware"
 )
 
 // @title Order Service API
@@ -17,6 +24,10 @@ import (
 // @description Order processing service with resilience patterns
 // @host localhost:8081
 // @BasePath /
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Admin token as "Bearer <ADMIN_TOKEN>"
 
 func main() {
 	// Get payment service URL from environment
@@ -25,14 +36,82 @@ func main() {
 		log.Fatal("PAYMENT_SERVICE_URL environment variable is required")
 	}
 
+	// Load circuit breaker settings from config file and environment
+	// Payment calls slower than 2s drain the bulkhead, so half of them slow opens the circuit
+	paymentBreakerDefaults := resilience.DefaultCircuitBreakerConfig()
+	paymentBreakerDefaults.SlowCallDuration = 2 * time.Second
+	paymentBreakerDefaults.SlowCallRateThreshold = 50
+	paymentBreakerDefaults.MinimumRequests = 5
+	paymentBreakerConfig, err := resilience.LoadCircuitBreakerConfig("payment", paymentBreakerDefaults)
+	if err != nil {
+		log.Fatalf("Invalid circuit breaker configuration: %v", err)
+	}
+
+	// Load bulkhead settings the same way
+	// Short bursts over capacity queue for up to 500ms instead of being rejected outright
+	paymentBulkheadDefaults := resilience.DefaultBulkheadConfig()
+	paymentBulkheadDefaults.MaxQueue = 20
+	paymentBulkheadDefaults.MaxWait = 500 * time.Millisecond
+	paymentBulkheadConfig, err := resilience.LoadBulkheadConfig("payment", paymentBulkheadDefaults)
+	if err != nil {
+		log.Fatalf("Invalid bulkhead configuration: %v", err)
+	}
+
+	// An adaptive limiter can replace the fixed-size bulkhead, e.g. ADAPTIVE_LIMITER_PAYMENT_ENABLED=true
+	paymentLimiterConfig, err := resilience.LoadAdaptiveLimiterConfig("payment", resilience.DefaultAdaptiveLimiterConfig())
+	if err != nil {
+		log.Fatalf("Invalid adaptive limiter configuration: %v", err)
+	}
+
+	paymentRetryConfig, err := resilience.LoadRetryConfig("payment", resilience.DefaultRetryConfig())
+	if err != nil {
+		log.Fatalf("Invalid retry configuration: %v", err)
+	}
+
+	// Orders are accepted as pending_payment when the payment circuit is open or the bulkhead full,
+	// e.g. FALLBACK_PAYMENT_ON_TIMEOUT=true also covers timeouts
+	paymentFallbackConfig, err := resilience.LoadFallbackConfig("payment", resilience.DefaultFallbackConfig())
+	if err != nil {
+		log.Fatalf("Invalid fallback configuration: %v", err)
+	}
+
+	// Responses to order creation are kept per Idempotency-Key so retries are safe
+	orderIdempotencyConfig, err := resilience.LoadIdempotencyConfig("orders", resilience.DefaultIdempotencyConfig())
+	if err != nil {
+		log.Fatalf("Invalid idempotency configuration: %v", err)
+	}
+	orderIdempotency := resilience.NewIdempotencyStore("orders", orderIdempotencyConfig.TTL)
+
+	// Requests inherit the deadline propagated by the caller
+	deadlineConfig, err := resilience.LoadDeadlineConfig("order-service", resilience.DefaultDeadlineConfig())
+	if err != nil {
+		log.Fatalf("Invalid deadline configuration: %v", err)
+	}
+
+	// Requests queue once 100 are in flight and are shed by priority when queueing falls behind
+	loadShedderConfig, err := resilience.LoadLoadShedderConfig("order-service", resilience.DefaultLoadShedderConfig())
+	if err != nil {
+		log.Fatalf("Invalid load shedder configuration: %v", err)
+	}
+	loadShedder := resilience.NewLoadShedder("order-service", loadShedderConfig, middleware.LoadShedInFlight)
+
 	// Setup router
 	router := gin.New()
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 	router.Use(middleware.MetricsMiddleware())
+	router.Use(resiliencemw.DeadlineMiddleware(deadlineConfig, middleware.RenderError))
 
 	// Initialize clients
-	paymentClient := client.NewPaymentClient(paymentServiceURL)
+	paymentClient, err := client.NewPaymentClient(paymentServiceURL, client.PaymentClientConfig{
+		CircuitBreaker:  paymentBreakerConfig,
+		Bulkhead:        paymentBulkheadConfig,
+		AdaptiveLimiter: paymentLimiterConfig,
+		Retry:           paymentRetryConfig,
+	})
+	if err != nil {
+		log.Fatalf("Invalid payment client configuration: %v", err)
+	}
 
 	// Root group
 	rootHandler := handlers.NewRootHandler()
@@ -46,13 +125,27 @@ func main() {
 	registerSwagger(router)
 
 	// API group
-	orderHandler := handlers.NewOrderHandler(paymentClient)
+	// Pending payments are retried in the background until they complete or fail
+	orders := store.NewOrderStore()
+	paymentWorker := worker.NewPaymentWorker(paymentClient, orders, paymentFallbackConfig)
+	go paymentWorker.Run(context.Background())
+
+	orderHandler := handlers.NewOrderHandler(paymentClient, orders, paymentWorker, paymentFallbackConfig)
 	api := router.Group("/api")
+	api.Use(middleware.LoadShedMiddleware(loadShedder))
 	{
-		api.POST("/orders", orderHandler.CreateOrder)
+		api.POST("/orders", resiliencemw.IdempotencyMiddleware(orderIdempotency, middleware.RenderError), orderHandler.CreateOrder)
 		api.GET("/orders/:id", orderHandler.GetOrder)
 	}
 
+	// Admin group, served only with an admin token to guard it
+	if adminToken := os.Getenv(admin.TokenEnv); adminToken != "" {
+		adminGroup := router.Group("/admin/resilience", admin.TokenMiddleware(adminToken))
+		admin.NewHandler(resilience.DefaultRegistry).Register(adminGroup)
+	} else {
+		log.Printf("Admin API disabled, set %s to enable it", admin.TokenEnv)
+	}
+
 	// Start server
 	log.Println("Order Service started")
 	if err := router.Run(":8081"); err != nil {
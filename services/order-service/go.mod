@@ -3,6 +3,7 @@ module github.com/LuoZihYuan/go-down/services/order-service
 go 1.25.1
 
 require (
+	github.com/LuoZihYuan/go-down/services/pkg/resilience v0.0.0-00010101000000-000000000000
 	github.com/gin-gonic/gin v1.11.0
 	github.com/google/uuid v1.6.0
 	github.com/prometheus/client_golang v1.19.1
@@ -61,3 +62,5 @@ require (
 	golang.org/x/tools v0.38.0 // indirect
 	google.golang.org/protobuf v1.36.9 // indirect
 )
+
+replace github.com/LuoZihYuan/go-down/services/pkg/resilience => ../pkg/resilience
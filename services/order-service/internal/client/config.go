@@ -0,0 +1,13 @@
+package client
+
+import "github.com/LuoZihYuan/go-down/services/pkg/resilience"
+
+// PaymentClientConfig tunes the resilience policies guarding the payment service
+// The stage client accepts it for parity and ignores it
+type PaymentClientConfig struct {
+	CircuitBreaker resilience.CircuitBreakerConfig
+	Bulkhead       resilience.BulkheadConfig
+	// AdaptiveLimiter replaces the fixed-size bulkhead when enabled
+	AdaptiveLimiter resilience.AdaptiveLimiterConfig
+	Retry           resilience.RetryConfig
+}
@@ -7,57 +7,80 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/LuoZihYuan/go-down/services/order-service/internal/models"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
 )
 
 // PaymentClient handles communication with the payment service
-// Resilient version: Includes timeout, circuit breaker, and bulkhead
+// Resilient version: Includes timeout, retry, circuit breaker, and bulkhead
 type PaymentClient struct {
 	httpClient     *http.Client
 	baseURL        string
-	circuitBreaker *CircuitBreaker[*models.PaymentResponse]
-	bulkhead       *Bulkhead
+	circuitBreaker *resilience.CircuitBreaker[*models.PaymentResponse]
+	bulkhead       resilience.ConcurrencyLimiter
+	retry          *resilience.Retry
+	policies       resilience.Policy
+	unsentPolicies resilience.Policy
 }
 
 // NewPaymentClient creates a new resilient payment client
-func NewPaymentClient(baseURL string) *PaymentClient {
-	return &PaymentClient{
+// config tunes the retry, circuit breaker and concurrency limiter guarding the payment service
+// Returns an error if the adaptive limiter configuration is invalid
+func NewPaymentClient(baseURL string, config PaymentClientConfig) (*PaymentClient, error) {
+	// Bulkhead: by default max 10 concurrent payment requests, with bursts waiting briefly in a queue
+	// An adaptive limiter takes its place when enabled in config
+	bulkhead, err := resilience.NewConcurrencyLimiter("payment", config.Bulkhead, config.AdaptiveLimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &PaymentClient{
 		httpClient: &http.Client{
 			Timeout: 3 * time.Second, // Fail fast timeout
 		},
 		baseURL: baseURL,
-		// Circuit breaker: 5 failures in 10 seconds opens circuit for 30 seconds
-		circuitBreaker: NewCircuitBreaker[*models.PaymentResponse]("payment", 5, 30*time.Second),
-		// Bulkhead: Max 10 concurrent payment requests
-		bulkhead: NewBulkhead("payment", 10),
+		// Circuit breaker: by default 5 failures in 10 seconds opens circuit for 30 seconds
+		circuitBreaker: resilience.NewCircuitBreaker[*models.PaymentResponse]("payment", resilience.WithConfig(config.CircuitBreaker)),
+		bulkhead:       bulkhead,
+		// Retry: by default 3 attempts with jittered backoff, capped by a budget of 10% of calls
+		retry: resilience.NewRetry("payment", resilience.WithRetryConfig(config.Retry)),
 	}
+
+	// Policies run outermost first: Retry -> Bulkhead -> CircuitBreaker -> HTTP call
+	// Bulkhead rejections don't count as circuit breaker failures, every attempt is
+	// counted by the circuit breaker, and an open circuit or full bulkhead ends the retries
+	c.policies = resilience.Chain(c.retry, c.bulkhead, c.circuitBreaker)
+	// Payments without an idempotency key are only retried when they were never sent
+	c.unsentPolicies = resilience.Chain(c.retry.UnsentOnly(), c.bulkhead, c.circuitBreaker)
+
+	return c, nil
 }
 
 // ProcessPayment sends a payment request to the payment service with resilience patterns
+// Without an idempotency key in ctx a repeated payment could charge twice,
+// so it is only retried when the payment service was never reached
 func (c *PaymentClient) ProcessPayment(ctx context.Context, req *models.PaymentRequest) (*models.PaymentResponse, error) {
 	var result *models.PaymentResponse
-	var callErr error
-
-	// Execute with bulkhead protection FIRST
-	// This ensures bulkhead rejections don't count as circuit breaker failures
-	bulkheadErr := c.bulkhead.TryExecute(func() error {
-		// Execute with circuit breaker protection INSIDE bulkhead
-		result, callErr = c.circuitBreaker.Execute(func() (*models.PaymentResponse, error) {
-			return c.makePaymentCall(ctx, req)
-		})
+
+	policies := c.policies
+	if _, ok := resilience.IdempotencyKeyFromContext(ctx); !ok {
+		policies = c.unsentPolicies
+	}
+
+	err := policies.Do(ctx, func() error {
+		var callErr error
+		result, callErr = c.makePaymentCall(ctx, req)
 		return callErr
 	})
-
-	// If bulkhead rejected, return that error directly
-	if bulkheadErr != nil {
-		return nil, bulkheadErr
+	if err != nil {
+		return nil, err
 	}
 
-	// Return the result from the circuit breaker/HTTP call
-	return result, callErr
+	return result, nil
 }
 
 // makePaymentCall performs the actual HTTP call
@@ -74,17 +97,24 @@ func (c *PaymentClient) makePaymentCall(ctx context.Context, req *models.Payment
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	if key, ok := resilience.IdempotencyKeyFromContext(ctx); ok {
+		httpReq.Header.Set(resilience.IdempotencyKeyHeader, key)
+	}
+
+	resilience.SetDeadlineHeader(httpReq)
 
-	// Send request (with 3s timeout from httpClient)
+	// Send request (with 3s timeout from httpClient, or less as set by the request deadline)
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, resilience.NewTransportError("payment", fmt.Errorf("failed to send request: %w", err))
 	}
 	defer resp.Body.Close()
 
 	// Check status code
+	// 4xx responses are classified as client errors and don't trip the circuit breaker
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("payment service returned status %d", resp.StatusCode)
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, resilience.NewStatusError("payment", resp.StatusCode, string(bodyBytes))
 	}
 
 	// Parse response
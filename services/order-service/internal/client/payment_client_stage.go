@@ -20,13 +20,14 @@ type PaymentClient struct {
 }
 
 // NewPaymentClient creates a new payment client
-func NewPaymentClient(baseURL string) *PaymentClient {
+// config is accepted for parity with the resilient client and ignored, so this never fails
+func NewPaymentClient(baseURL string, config PaymentClientConfig) (*PaymentClient, error) {
 	return &PaymentClient{
 		httpClient: &http.Client{
 			// No timeout in stage - allows full cascade failure
 		},
 		baseURL: baseURL,
-	}
+	}, nil
 }
 
 // ProcessPayment sends a payment request to the payment service
@@ -1,9 +1,10 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,34 +12,49 @@ import (
 
 	"github.com/LuoZihYuan/go-down/services/order-service/internal/client"
 	"github.com/LuoZihYuan/go-down/services/order-service/internal/models"
+	"github.com/LuoZihYuan/go-down/services/order-service/internal/store"
+	"github.com/LuoZihYuan/go-down/services/order-service/internal/worker"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
 )
 
 // OrderHandler handles order-related requests
 type OrderHandler struct {
 	paymentClient *client.PaymentClient
-	orders        map[string]*models.OrderResponse
-	mu            sync.RWMutex
+	orders        *store.OrderStore
+	paymentWorker *worker.PaymentWorker
+	fallback      resilience.FallbackConfig
 }
 
 // NewOrderHandler creates a new order handler
-func NewOrderHandler(paymentClient *client.PaymentClient) *OrderHandler {
+// Orders whose payment fails with an error covered by fallback are accepted
+// as pending_payment and their payment is handed to paymentWorker
+func NewOrderHandler(paymentClient *client.PaymentClient, orders *store.OrderStore, paymentWorker *worker.PaymentWorker, fallback resilience.FallbackConfig) *OrderHandler {
 	return &OrderHandler{
 		paymentClient: paymentClient,
-		orders:        make(map[string]*models.OrderResponse),
+		orders:        orders,
+		paymentWorker: paymentWorker,
+		fallback:      fallback,
 	}
 }
 
 // CreateOrder processes a new order
 // @Summary Create order
 // @Description Creates a new order and processes payment
+// @Description If the payment service is unavailable the order may be accepted with status pending_payment
+// @Description and 202; its payment is retried in the background until it is completed or payment_failed
 // @Tags Orders
 // @Accept json
 // @Produce json
 // @Param order body models.OrderRequest true "Order request"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe; repeats replay the first response"
+// @Param X-Priority header string false "Load shedding priority: critical, normal (default) or sheddable"
 // @Success 200 {object} models.OrderResponse
+// @Success 202 {object} models.OrderResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Failure 503 {object} models.ErrorResponse
+// @Failure 504 {object} models.ErrorResponse
 // @Router /api/orders [post]
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var req models.OrderRequest
@@ -52,7 +68,14 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	}
 
 	// Generate order ID
-	orderID := fmt.Sprintf("order-%s", uuid.New().String()[:8])
+	// A retried request carries the same Idempotency-Key and gets the same order ID,
+	// so the payment service recognizes the repeated payment
+	ctx := c.Request.Context()
+	orderUUID := uuid.New()
+	if key, ok := resilience.IdempotencyKeyFromContext(ctx); ok {
+		orderUUID = uuid.NewSHA1(uuid.NameSpaceURL, []byte(key))
+	}
+	orderID := fmt.Sprintf("order-%s", orderUUID.String()[:8])
 
 	// Process payment
 	paymentReq := &models.PaymentRequest{
@@ -61,10 +84,33 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		Method:  "credit_card",
 	}
 
-	paymentResp, err := h.paymentClient.ProcessPayment(c.Request.Context(), paymentReq)
+	// Without a client key the order ID keys the payment, making internal retries safe
+	if _, ok := resilience.IdempotencyKeyFromContext(ctx); !ok {
+		ctx = resilience.WithIdempotencyKey(ctx, orderID)
+	}
+
+	paymentResp, err := h.paymentClient.ProcessPayment(ctx, paymentReq)
 	if err != nil {
+		// Accept the order and retry its payment later if the fallback covers the error
+		if h.fallback.Covers(err) {
+			key, _ := resilience.IdempotencyKeyFromContext(ctx)
+			order := &models.OrderResponse{
+				OrderID:    orderID,
+				CustomerID: req.CustomerID,
+				Amount:     req.Amount,
+				Status:     models.OrderStatusPendingPayment,
+				Items:      req.Items,
+				CreatedAt:  time.Now(),
+			}
+			h.orders.Save(order)
+			h.paymentWorker.Enqueue(*paymentReq, key)
+
+			c.JSON(http.StatusAccepted, order)
+			return
+		}
+
 		// Handle different error types
-		if err == client.ErrCircuitOpen {
+		if errors.Is(err, resilience.ErrCircuitOpen) {
 			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
 				Title:  "Service Unavailable",
 				Status: http.StatusServiceUnavailable,
@@ -72,7 +118,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 			})
 			return
 		}
-		if err == client.ErrBulkheadFull {
+		if errors.Is(err, resilience.ErrBulkheadFull) {
 			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
 				Title:  "Service Unavailable",
 				Status: http.StatusServiceUnavailable,
@@ -81,6 +127,15 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, context.DeadlineExceeded) {
+			c.JSON(http.StatusGatewayTimeout, models.ErrorResponse{
+				Title:  "Gateway Timeout",
+				Status: http.StatusGatewayTimeout,
+				Detail: "Payment service did not respond within the request deadline",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Title:  "Internal Server Error",
 			Status: http.StatusInternalServerError,
@@ -94,16 +149,14 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		OrderID:    orderID,
 		CustomerID: req.CustomerID,
 		Amount:     req.Amount,
-		Status:     "completed",
+		Status:     models.OrderStatusCompleted,
 		PaymentID:  paymentResp.PaymentID,
 		Items:      req.Items,
 		CreatedAt:  time.Now(),
 	}
 
 	// Store order (in-memory for demo)
-	h.mu.Lock()
-	h.orders[orderID] = order
-	h.mu.Unlock()
+	h.orders.Save(order)
 
 	c.JSON(http.StatusOK, order)
 }
@@ -114,15 +167,15 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 // @Tags Orders
 // @Produce json
 // @Param id path string true "Order ID"
+// @Param X-Priority header string false "Load shedding priority: critical, normal (default) or sheddable"
 // @Success 200 {object} models.OrderResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
 // @Router /api/orders/{id} [get]
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	orderID := c.Param("id")
 
-	h.mu.RLock()
-	order, exists := h.orders[orderID]
-	h.mu.RUnlock()
+	order, exists := h.orders.Get(orderID)
 
 	if !exists {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
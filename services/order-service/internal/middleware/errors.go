@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/order-service/internal/models"
+)
+
+// RenderError answers a request rejected by a middleware with an ErrorResponse
+// It is the error renderer handed to the shared resilience middleware
+func RenderError(c *gin.Context, status int, detail string) {
+	c.AbortWithStatusJSON(status, models.ErrorResponse{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
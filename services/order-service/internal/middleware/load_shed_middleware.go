@@ -0,0 +1,62 @@
+//go:build !stage
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/order-service/internal/models"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+// loadShedRetryAfter tells shed clients how many seconds to back off
+const loadShedRetryAfter = "1"
+
+// loadShedInFlight counts the requests inside LoadShedMiddleware, queued ones included
+var loadShedInFlight atomic.Int64
+
+// LoadShedInFlight returns the requests inside LoadShedMiddleware, for the load shedder
+// Unlike http_requests_in_flight it leaves out health checks and admin requests,
+// which are never shed and must not push order traffic into shedding
+func LoadShedInFlight() int {
+	return int(loadShedInFlight.Load())
+}
+
+// LoadShedMiddleware sheds requests once the service falls behind, lowest priority first
+// The priority is read from the X-Priority header set by the gateway
+// Resilient version: Shed requests fail fast with 503 and Retry-After
+func LoadShedMiddleware(shedder *resilience.LoadShedder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		priority := resilience.ParsePriority(c.GetHeader(resilience.PriorityHeader))
+
+		loadShedInFlight.Add(1)
+		defer loadShedInFlight.Add(-1)
+
+		release, err := shedder.Admit(c.Request.Context(), priority)
+		if errors.Is(err, context.DeadlineExceeded) {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, models.ErrorResponse{
+				Title:  "Gateway Timeout",
+				Status: http.StatusGatewayTimeout,
+				Detail: "Request deadline exceeded while queued",
+			})
+			return
+		}
+		if err != nil {
+			c.Header("Retry-After", loadShedRetryAfter)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Title:  "Service Unavailable",
+				Status: http.StatusServiceUnavailable,
+				Detail: "Service overloaded, " + priority.String() + " request shed",
+			})
+			return
+		}
+		defer release()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+//go:build stage
+
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+// LoadShedInFlight returns 0, as no requests are counted
+// Stage version: No load shedding - the load shedder is never consulted
+func LoadShedInFlight() int {
+	return 0
+}
+
+// LoadShedMiddleware admits every request
+// Stage version: No load shedding - requests pile up until the bulkhead rejects them
+func LoadShedMiddleware(shedder *resilience.LoadShedder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+	}
+}
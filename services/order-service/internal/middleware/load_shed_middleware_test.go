@@ -0,0 +1,44 @@
+//go:build !stage
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+func TestLoadShedInFlightCountsShedRoutesOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	shedder := resilience.NewLoadShedder("test-order-service", resilience.DefaultLoadShedderConfig(), LoadShedInFlight)
+
+	seen := map[string]int{}
+	record := func(c *gin.Context) {
+		seen[c.Request.URL.Path] = LoadShedInFlight()
+		c.Status(http.StatusOK)
+	}
+	router := gin.New()
+	router.Use(MetricsMiddleware())
+	router.GET("/health", record)
+	api := router.Group("/api")
+	api.Use(LoadShedMiddleware(shedder))
+	api.GET("/orders/:id", record)
+
+	for _, path := range []string{"/health", "/api/orders/1"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if seen["/health"] != 0 {
+		t.Errorf("in flight during /health = %d, want 0", seen["/health"])
+	}
+	if seen["/api/orders/1"] != 1 {
+		t.Errorf("in flight during an order request = %d, want 1", seen["/api/orders/1"])
+	}
+	if got := LoadShedInFlight(); got != 0 {
+		t.Errorf("in flight after the requests = %d, want 0", got)
+	}
+}
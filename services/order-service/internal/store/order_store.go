@@ -0,0 +1,51 @@
+package store
+
+import (
+	"sync"
+
+	"github.com/LuoZihYuan/go-down/services/order-service/internal/models"
+)
+
+// OrderStore keeps orders in memory (for demo)
+// Stored orders are never modified in place; Save replaces them, so an order
+// returned by Get can be read while its status changes
+type OrderStore struct {
+	mu     sync.RWMutex
+	orders map[string]*models.OrderResponse
+}
+
+// NewOrderStore creates an empty order store
+func NewOrderStore() *OrderStore {
+	return &OrderStore{
+		orders: make(map[string]*models.OrderResponse),
+	}
+}
+
+// Get looks up an order by ID
+func (s *OrderStore) Get(orderID string) (*models.OrderResponse, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	order, ok := s.orders[orderID]
+	return order, ok
+}
+
+// Save stores an order, replacing any with the same ID
+func (s *OrderStore) Save(order *models.OrderResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders[order.OrderID] = order
+}
+
+// UpdateStatus replaces an order with a copy in the given status and payment
+func (s *OrderStore) UpdateStatus(orderID, status, paymentID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[orderID]
+	if !ok {
+		return
+	}
+	updated := *order
+	updated.Status = status
+	updated.PaymentID = paymentID
+	s.orders[orderID] = &updated
+}
@@ -0,0 +1,164 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/LuoZihYuan/go-down/services/order-service/internal/models"
+	"github.com/LuoZihYuan/go-down/services/order-service/internal/store"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+var (
+	// Orders waiting for their payment to be retried
+	pendingPayments = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "pending_payments",
+			Help: "Current number of orders accepted with their payment pending",
+		},
+	)
+
+	// Outcome of pending payments
+	pendingPaymentsResolved = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pending_payments_resolved_total",
+			Help: "Total number of pending payments resolved by final order status (completed, payment_failed)",
+		},
+		[]string{"status"},
+	)
+)
+
+// PaymentProcessor makes payments, such as the payment service client
+type PaymentProcessor interface {
+	ProcessPayment(ctx context.Context, req *models.PaymentRequest) (*models.PaymentResponse, error)
+}
+
+// pendingPayment is a payment accepted under the fallback and not made yet
+type pendingPayment struct {
+	request        models.PaymentRequest
+	idempotencyKey string
+	attempts       int
+}
+
+// PaymentWorker retries the payments of orders accepted while the payment service was unavailable
+// Each retry uses the idempotency key of the original attempt, so a payment that did
+// go through is not charged twice
+type PaymentWorker struct {
+	mu            sync.Mutex
+	pending       map[string]*pendingPayment // by order ID
+	paymentClient PaymentProcessor
+	orders        *store.OrderStore
+	retryInterval time.Duration
+	maxAttempts   int
+}
+
+// NewPaymentWorker creates a payment worker
+// cfg sets how often payments are retried and how many failures end in payment_failed
+func NewPaymentWorker(paymentClient PaymentProcessor, orders *store.OrderStore, cfg resilience.FallbackConfig) *PaymentWorker {
+	// Initialize metrics to 0 so they show up in Grafana immediately
+	pendingPayments.Set(0)
+	pendingPaymentsResolved.WithLabelValues(models.OrderStatusCompleted).Add(0)
+	pendingPaymentsResolved.WithLabelValues(models.OrderStatusPaymentFailed).Add(0)
+
+	return &PaymentWorker{
+		pending:       make(map[string]*pendingPayment),
+		paymentClient: paymentClient,
+		orders:        orders,
+		retryInterval: cfg.RetryInterval,
+		maxAttempts:   cfg.MaxAttempts,
+	}
+}
+
+// Enqueue schedules the payment of an order for retry
+func (w *PaymentWorker) Enqueue(req models.PaymentRequest, idempotencyKey string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.pending[req.OrderID]; ok {
+		return
+	}
+	w.pending[req.OrderID] = &pendingPayment{request: req, idempotencyKey: idempotencyKey}
+	pendingPayments.Set(float64(len(w.pending)))
+}
+
+// Run retries pending payments every retry interval until ctx is done
+func (w *PaymentWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.retryPending(ctx)
+		}
+	}
+}
+
+// retryPending makes one attempt at every pending payment
+func (w *PaymentWorker) retryPending(ctx context.Context) {
+	w.mu.Lock()
+	batch := make([]*pendingPayment, 0, len(w.pending))
+	for _, p := range w.pending {
+		batch = append(batch, p)
+	}
+	w.mu.Unlock()
+
+	for _, p := range batch {
+		if ctx.Err() != nil {
+			return
+		}
+		if !w.retry(ctx, p) {
+			// The circuit is open or the bulkhead full; wait for the next round
+			return
+		}
+	}
+}
+
+// retry attempts a pending payment and records the outcome
+// Returns false if the payment service could not be called at all
+func (w *PaymentWorker) retry(ctx context.Context, p *pendingPayment) bool {
+	req := p.request
+	resp, err := w.paymentClient.ProcessPayment(resilience.WithIdempotencyKey(ctx, p.idempotencyKey), &req)
+	switch {
+	case err == nil:
+		w.resolve(req.OrderID, models.OrderStatusCompleted, resp.PaymentID)
+	case resilience.IsRejected(err):
+		// Not an attempt: the payment service was not called
+		return false
+	case isDeclined(err):
+		log.Printf("Payment for order %s declined: %v", req.OrderID, err)
+		w.resolve(req.OrderID, models.OrderStatusPaymentFailed, "")
+	default:
+		p.attempts++
+		if p.attempts >= w.maxAttempts {
+			log.Printf("Payment for order %s failed after %d attempts: %v", req.OrderID, p.attempts, err)
+			w.resolve(req.OrderID, models.OrderStatusPaymentFailed, "")
+		}
+	}
+	return true
+}
+
+// resolve settles the status of an order and stops retrying its payment
+func (w *PaymentWorker) resolve(orderID, status, paymentID string) {
+	w.orders.UpdateStatus(orderID, status, paymentID)
+	pendingPaymentsResolved.WithLabelValues(status).Inc()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.pending, orderID)
+	pendingPayments.Set(float64(len(w.pending)))
+}
+
+// isDeclined reports whether the payment service rejected the payment itself,
+// so repeating it cannot succeed
+func isDeclined(err error) bool {
+	var upstreamErr *resilience.UpstreamError
+	return errors.As(err, &upstreamErr) && upstreamErr.IsClientError() && !upstreamErr.Retryable
+}
@@ -0,0 +1,139 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/LuoZihYuan/go-down/services/order-service/internal/models"
+	"github.com/LuoZihYuan/go-down/services/order-service/internal/store"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+// paymentClient fakes the payment service client, answering each call with the next
+// scripted error, and succeeding once the script runs out
+type paymentClient struct {
+	mu   sync.Mutex
+	errs []error
+	keys []string // idempotency key of every call
+}
+
+func (c *paymentClient) ProcessPayment(ctx context.Context, req *models.PaymentRequest) (*models.PaymentResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, _ := resilience.IdempotencyKeyFromContext(ctx)
+	c.keys = append(c.keys, key)
+
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &models.PaymentResponse{PaymentID: "pay-" + req.OrderID, OrderID: req.OrderID, Status: "success"}, nil
+}
+
+// newTestWorker returns a worker with the given orders pending payment
+func newTestWorker(payments *paymentClient, orderIDs ...string) (*PaymentWorker, *store.OrderStore) {
+	orders := store.NewOrderStore()
+	w := NewPaymentWorker(payments, orders, resilience.FallbackConfig{MaxAttempts: 3})
+	for _, id := range orderIDs {
+		orders.Save(&models.OrderResponse{OrderID: id, Status: models.OrderStatusPendingPayment})
+		w.Enqueue(models.PaymentRequest{OrderID: id, Amount: 10, Method: "credit_card"}, "key-"+id)
+	}
+	return w, orders
+}
+
+func TestPaymentWorkerRetry(t *testing.T) {
+	unavailable := resilience.NewStatusError("payment", http.StatusServiceUnavailable, "")
+	declined := resilience.NewStatusError("payment", http.StatusBadRequest, "")
+
+	tests := []struct {
+		name          string
+		errs          []error
+		rounds        int
+		wantStatus    string
+		wantPaymentID string
+		wantPending   bool
+	}{
+		{"succeeds first round", nil, 1, models.OrderStatusCompleted, "pay-order-1", false},
+		{"succeeds after a failure", []error{unavailable}, 2, models.OrderStatusCompleted, "pay-order-1", false},
+		{"keeps pending below max attempts", []error{unavailable, unavailable}, 2, models.OrderStatusPendingPayment, "", true},
+		{"fails after max attempts", []error{unavailable, unavailable, unavailable}, 3, models.OrderStatusPaymentFailed, "", false},
+		{"fails when declined", []error{declined}, 1, models.OrderStatusPaymentFailed, "", false},
+		{"rejected is not an attempt", []error{resilience.ErrCircuitOpen, unavailable, unavailable, nil}, 4, models.OrderStatusCompleted, "pay-order-1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments := &paymentClient{errs: tt.errs}
+			w, orders := newTestWorker(payments, "order-1")
+
+			for range tt.rounds {
+				w.retryPending(context.Background())
+			}
+
+			order, _ := orders.Get("order-1")
+			if order.Status != tt.wantStatus || order.PaymentID != tt.wantPaymentID {
+				t.Errorf("order = %s with payment %q, want %s with payment %q", order.Status, order.PaymentID, tt.wantStatus, tt.wantPaymentID)
+			}
+			if _, pending := w.pending["order-1"]; pending != tt.wantPending {
+				t.Errorf("pending = %v, want %v", pending, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestPaymentWorkerReusesIdempotencyKey(t *testing.T) {
+	unavailable := resilience.NewTransportError("payment", errors.New("connection reset"))
+	payments := &paymentClient{errs: []error{unavailable, unavailable}}
+	w, _ := newTestWorker(payments, "order-1")
+
+	for range 3 {
+		w.retryPending(context.Background())
+	}
+
+	if len(payments.keys) != 3 {
+		t.Fatalf("payment calls = %d, want 3", len(payments.keys))
+	}
+	for n, key := range payments.keys {
+		if key != "key-order-1" {
+			t.Errorf("call %d idempotency key = %q, want %q", n+1, key, "key-order-1")
+		}
+	}
+}
+
+func TestPaymentWorkerRejectedStopsRound(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"circuit open", resilience.ErrCircuitOpen},
+		{"bulkhead full", resilience.ErrBulkheadFull},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments := &paymentClient{errs: []error{tt.err, tt.err, tt.err}}
+			w, orders := newTestWorker(payments, "order-1", "order-2", "order-3")
+
+			w.retryPending(context.Background())
+
+			if len(payments.keys) != 1 {
+				t.Errorf("payment calls = %d, want 1", len(payments.keys))
+			}
+			for _, id := range []string{"order-1", "order-2", "order-3"} {
+				if order, _ := orders.Get(id); order.Status != models.OrderStatusPendingPayment {
+					t.Errorf("order %s = %s, want %s", id, order.Status, models.OrderStatusPendingPayment)
+				}
+				if p := w.pending[id]; p == nil || p.attempts != 0 {
+					t.Errorf("order %s pending = %+v, want pending without attempts", id, p)
+				}
+			}
+		})
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/LuoZihYuan/go-down/services/payment-service/internal/fault"
 	"github.com/LuoZihYuan/go-down/services/payment-service/internal/handlers"
 	"github.com/LuoZihYuan/go-down/services/payment-service/internal/middleware"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+	resiliencemw "github.com/LuoZihYuan/go-down/services/pkg/resilience/middleware"
 )
 
 // @title Payment Service API
@@ -18,12 +20,25 @@ import (
 // @BasePath /
 
 func main() {
+	// Responses to payments are kept per Idempotency-Key so retries never charge twice
+	paymentIdempotencyConfig, err := resilience.LoadIdempotencyConfig("payments", resilience.DefaultIdempotencyConfig())
+	if err != nil {
+		log.Fatalf("Invalid idempotency configuration: %v", err)
+	}
+	paymentIdempotency := resilience.NewIdempotencyStore("payments", paymentIdempotencyConfig.TTL)
+
+	// Requests inherit the deadline propagated by the caller
+	deadlineConfig, err := resilience.LoadDeadlineConfig("payment-service", resilience.DefaultDeadlineConfig())
+	if err != nil {
+		log.Fatalf("Invalid deadline configuration: %v", err)
+	}
 
 	// Setup router
 	router := gin.New()
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 	router.Use(middleware.MetricsMiddleware())
+	router.Use(resiliencemw.DeadlineMiddleware(deadlineConfig, middleware.RenderError))
 
 	// Initialize fault injector
 	faultInjector := fault.NewInjector()
@@ -40,7 +55,7 @@ func main() {
 	paymentHandler := handlers.NewPaymentHandler(faultInjector)
 	api := router.Group("/api")
 	{
-		api.POST("/payments", paymentHandler.ProcessPayment)
+		api.POST("/payments", resiliencemw.IdempotencyMiddleware(paymentIdempotency, middleware.RenderError), paymentHandler.ProcessPayment)
 	}
 
 	// Chaos group
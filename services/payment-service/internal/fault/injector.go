@@ -1,31 +1,205 @@
 package fault
 
 import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
 	"sync"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	faultsInjected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chaos_faults_injected_total",
+			Help: "Total number of faults injected into requests by type (latency, error_500, error_503, error_429, abort, malformed_json)",
+		},
+		[]string{"fault"},
+	)
+
+	injectionsAborted = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "chaos_injections_aborted_total",
+			Help: "Total number of injected delays cut short because the request was cancelled",
+		},
+	)
+)
+
+// latencyLabel is the metric label of injected delays
+const latencyLabel = "latency"
+
+// Fault is the failure injected into a single request
+type Fault int
+
+const (
+	// None lets the request through unharmed
+	None Fault = iota
+	// Error500 responds with 500 Internal Server Error
+	Error500
+	// Error503 responds with 503 Service Unavailable
+	Error503
+	// Error429 responds with 429 Too Many Requests
+	Error429
+	// Abort closes the connection without a response
+	Abort
+	// MalformedJSON responds 200 with a body that is not valid JSON
+	MalformedJSON
 )
 
+// String returns the metric label of the fault
+func (f Fault) String() string {
+	switch f {
+	case Error500:
+		return "error_500"
+	case Error503:
+		return "error_503"
+	case Error429:
+		return "error_429"
+	case Abort:
+		return "abort"
+	case MalformedJSON:
+		return "malformed_json"
+	default:
+		return "none"
+	}
+}
+
+// StatusCode returns the HTTP status of an error fault, or 0 for other faults
+func (f Fault) StatusCode() int {
+	switch f {
+	case Error500:
+		return http.StatusInternalServerError
+	case Error503:
+		return http.StatusServiceUnavailable
+	case Error429:
+		return http.StatusTooManyRequests
+	default:
+		return 0
+	}
+}
+
+// Config describes the faults to inject and the requests they target
+// Fault percentages are independent of the latency and are drawn from a single roll,
+// so together they must not exceed 100
+type Config struct {
+	Match            Match
+	Latency          Latency
+	Error500Percent  float64
+	Error503Percent  float64
+	Error429Percent  float64
+	AbortPercent     float64
+	MalformedPercent float64
+}
+
+// Validate reports whether the match and latency are valid and the fault percentages add up to at most 100
+func (c Config) Validate() error {
+	if err := c.Match.Validate(); err != nil {
+		return err
+	}
+	if err := c.Latency.Validate(); err != nil {
+		return err
+	}
+
+	total := 0.0
+	for _, p := range c.percentages() {
+		if p.percent < 0 || p.percent > 100 {
+			return errors.New("fault percentages must be between 0 and 100")
+		}
+		total += p.percent
+	}
+	if total > 100 {
+		return errors.New("fault percentages must not add up to more than 100")
+	}
+	return nil
+}
+
+// faultPercent is the chance of a single fault
+type faultPercent struct {
+	fault   Fault
+	percent float64
+}
+
+// percentages lists the chance of every fault
+func (c Config) percentages() []faultPercent {
+	return []faultPercent{
+		{Error500, c.Error500Percent},
+		{Error503, c.Error503Percent},
+		{Error429, c.Error429Percent},
+		{Abort, c.AbortPercent},
+		{MalformedJSON, c.MalformedPercent},
+	}
+}
+
+// Stage is one step of a chaos schedule
+type Stage struct {
+	Config   Config
+	Duration time.Duration
+}
+
+// Validate reports whether the stage has a valid configuration and a positive duration
+func (s Stage) Validate() error {
+	if s.Duration <= 0 {
+		return errors.New("stage duration must be positive")
+	}
+	return s.Config.Validate()
+}
+
+// Status is the fault injection in effect at a point in time
+type Status struct {
+	Enabled bool
+	// Config is the configuration of the current stage
+	Config Config
+	Stages []Stage
+	// Stage is the index of the current stage
+	Stage int
+	// StageRemaining is the time left in the current stage, and Remaining the time
+	// left until injection reverts; both are 0 when it runs until disabled
+	StageRemaining time.Duration
+	Remaining      time.Duration
+}
+
 // Injector manages fault injection state
+// Injection follows a schedule of stages from the time it was enabled, and
+// reverts to disabled once the last stage is over
 type Injector struct {
-	enabled      bool
-	delaySeconds int
-	mu           sync.RWMutex
+	enabled   bool
+	stages    []Stage
+	startedAt time.Time
+	mu        sync.RWMutex
 }
 
 // NewInjector creates a new fault injector
 func NewInjector() *Injector {
+	// Initialize metrics to 0 so they show up in Grafana immediately
+	faultsInjected.WithLabelValues(latencyLabel).Add(0)
+	for _, p := range (Config{}).percentages() {
+		faultsInjected.WithLabelValues(p.fault.String()).Add(0)
+	}
+	injectionsAborted.Add(0)
+
 	return &Injector{
-		enabled:      false,
-		delaySeconds: 0,
+		enabled: false,
 	}
 }
 
-// Enable activates fault injection with specified delay
-func (i *Injector) Enable(delaySeconds int) {
+// Enable activates fault injection with the specified faults
+// Injection reverts after duration, or runs until disabled when duration is 0
+func (i *Injector) Enable(cfg Config, duration time.Duration) {
+	i.EnableSchedule([]Stage{{Config: cfg, Duration: duration}})
+}
+
+// EnableSchedule activates fault injection that steps through stages in order
+// and reverts once the last one is over
+func (i *Injector) EnableSchedule(stages []Stage) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 	i.enabled = true
-	i.delaySeconds = delaySeconds
+	i.stages = stages
+	i.startedAt = time.Now()
 }
 
 // Disable deactivates fault injection
@@ -33,32 +207,85 @@ func (i *Injector) Disable() {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 	i.enabled = false
-	i.delaySeconds = 0
+	i.stages = nil
 }
 
 // IsEnabled returns whether fault injection is active
 func (i *Injector) IsEnabled() bool {
-	i.mu.RLock()
-	defer i.mu.RUnlock()
-	return i.enabled
+	return i.Status().Enabled
 }
 
-// GetStatus returns current fault injection configuration
-func (i *Injector) GetStatus() (bool, int) {
+// Status returns the fault injection in effect now
+func (i *Injector) Status() Status {
 	i.mu.RLock()
 	defer i.mu.RUnlock()
-	return i.enabled, i.delaySeconds
+	return i.statusAt(time.Now())
 }
 
-// Inject applies fault injection if enabled
-// This blocks for the configured delay duration
-func (i *Injector) Inject() {
-	i.mu.RLock()
-	enabled := i.enabled
-	delay := i.delaySeconds
-	i.mu.RUnlock()
+// statusAt returns the fault injection in effect at now
+// Callers hold i.mu
+func (i *Injector) statusAt(now time.Time) Status {
+	if !i.enabled {
+		return Status{}
+	}
+
+	var total time.Duration
+	for _, s := range i.stages {
+		total += s.Duration
+	}
+
+	elapsed := now.Sub(i.startedAt)
+	var end time.Duration
+	for n, s := range i.stages {
+		status := Status{Enabled: true, Config: s.Config, Stages: i.stages, Stage: n}
+		if s.Duration == 0 {
+			// Enabled without a duration, runs until disabled
+			return status
+		}
+		end += s.Duration
+		if elapsed < end {
+			status.StageRemaining = end - elapsed
+			status.Remaining = total - elapsed
+			return status
+		}
+	}
+
+	// The schedule is over, injection has reverted
+	return Status{}
+}
+
+// Inject applies fault injection if enabled and target matches
+// This blocks for a delay drawn from the latency of the current stage, then picks the fault
+// the caller should apply to the request
+// Returns the context error if ctx is done during the delay, so that abandoned
+// requests stop holding their goroutine
+func (i *Injector) Inject(ctx context.Context, target Target) (Fault, error) {
+	status := i.Status()
+	cfg := status.Config
+
+	if !status.Enabled || !cfg.Match.Matches(target) {
+		return None, nil
+	}
+
+	if delay := cfg.Latency.sample(); delay > 0 {
+		faultsInjected.WithLabelValues(latencyLabel).Inc()
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			injectionsAborted.Inc()
+			return None, ctx.Err()
+		}
+	}
 
-	if enabled && delay > 0 {
-		time.Sleep(time.Duration(delay) * time.Second)
+	roll := rand.Float64() * 100
+	for _, p := range cfg.percentages() {
+		if roll < p.percent {
+			faultsInjected.WithLabelValues(p.fault.String()).Inc()
+			return p.fault, nil
+		}
+		roll -= p.percent
 	}
+	return None, nil
 }
@@ -0,0 +1,247 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"no faults", Config{}, false},
+		{"single fault at 100", Config{Error500Percent: 100}, false},
+		{"faults adding up to 100", Config{Error500Percent: 40, Error503Percent: 30, Error429Percent: 10, AbortPercent: 10, MalformedPercent: 10}, false},
+		{"faults above 100", Config{Error500Percent: 60, Error503Percent: 41}, true},
+		{"negative fault", Config{Error429Percent: -1}, true},
+		{"negative fault offsetting another", Config{Error500Percent: 110, AbortPercent: -10}, true},
+		{"fault above 100", Config{MalformedPercent: 101}, true},
+		{"latency does not count towards faults", Config{Latency: Latency{Distribution: DistributionFixed, Percent: 100, FixedMs: 10}, Error503Percent: 100}, false},
+		{"invalid latency", Config{Latency: Latency{Distribution: DistributionFixed, Percent: 50}}, true},
+		{"invalid match", Config{Match: Match{OrderPercent: 101}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInjectFault(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		cfg     Config
+		want    Fault
+	}{
+		{"disabled", false, Config{Error500Percent: 100}, None},
+		{"no faults", true, Config{}, None},
+		{"error 500", true, Config{Error500Percent: 100}, Error500},
+		{"error 503", true, Config{Error503Percent: 100}, Error503},
+		{"error 429", true, Config{Error429Percent: 100}, Error429},
+		{"abort", true, Config{AbortPercent: 100}, Abort},
+		{"malformed json", true, Config{MalformedPercent: 100}, MalformedJSON},
+		{"unmatched request", true, Config{Match: Match{Method: "GET"}, Error500Percent: 100}, None},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector := NewInjector()
+			if tt.enabled {
+				injector.Enable(tt.cfg, 0)
+			}
+
+			got, err := injector.Inject(context.Background(), Target{Method: "POST"})
+			if err != nil {
+				t.Fatalf("Inject() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Inject() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInjectStopsWhenContextDone(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		wantErr error
+	}{
+		{
+			name: "cancelled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(20*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "deadline exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 20*time.Millisecond)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector := NewInjector()
+			injector.Enable(Config{
+				Latency:         Latency{Distribution: DistributionFixed, Percent: 100, FixedMs: 60000},
+				Error500Percent: 100,
+			}, 0)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			aborted := testutil.ToFloat64(injectionsAborted)
+			errors500 := testutil.ToFloat64(faultsInjected.WithLabelValues(Error500.String()))
+
+			start := time.Now()
+			got, err := injector.Inject(ctx, Target{})
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("Inject() returned after %v, want it to stop with the context", elapsed)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Inject() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != None {
+				t.Errorf("Inject() = %v, want %v", got, None)
+			}
+			if d := testutil.ToFloat64(injectionsAborted) - aborted; d != 1 {
+				t.Errorf("chaos_injections_aborted_total increased by %v, want 1", d)
+			}
+			if d := testutil.ToFloat64(faultsInjected.WithLabelValues(Error500.String())) - errors500; d != 0 {
+				t.Errorf("error_500 faults increased by %v after an aborted delay, want 0", d)
+			}
+		})
+	}
+}
+
+func TestInjectCountsFaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantLatency float64
+		wantFault   Fault
+	}{
+		{"no faults", Config{}, 0, None},
+		{"latency only", Config{Latency: Latency{Distribution: DistributionFixed, Percent: 100, FixedMs: 1}}, 1, None},
+		{"error only", Config{Error503Percent: 100}, 0, Error503},
+		{"latency and abort", Config{Latency: Latency{Distribution: DistributionFixed, Percent: 100, FixedMs: 1}, AbortPercent: 100}, 1, Abort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector := NewInjector()
+			injector.Enable(tt.cfg, 0)
+
+			before := map[string]float64{latencyLabel: testutil.ToFloat64(faultsInjected.WithLabelValues(latencyLabel))}
+			for _, p := range tt.cfg.percentages() {
+				before[p.fault.String()] = testutil.ToFloat64(faultsInjected.WithLabelValues(p.fault.String()))
+			}
+
+			got, err := injector.Inject(context.Background(), Target{})
+			if err != nil {
+				t.Fatalf("Inject() error = %v", err)
+			}
+			if got != tt.wantFault {
+				t.Errorf("Inject() = %v, want %v", got, tt.wantFault)
+			}
+
+			for label, count := range before {
+				want := 0.0
+				if label == latencyLabel {
+					want = tt.wantLatency
+				} else if label == tt.wantFault.String() {
+					want = 1
+				}
+				if d := testutil.ToFloat64(faultsInjected.WithLabelValues(label)) - count; d != want {
+					t.Errorf("%s faults increased by %v, want %v", label, d, want)
+				}
+			}
+		})
+	}
+}
+
+func TestInjectorStatusAt(t *testing.T) {
+	first := Config{Error500Percent: 10}
+	second := Config{Error500Percent: 50}
+	ramp := []Stage{{Config: first, Duration: time.Minute}, {Config: second, Duration: 2 * time.Minute}}
+
+	tests := []struct {
+		name               string
+		stages             []Stage
+		elapsed            time.Duration
+		wantEnabled        bool
+		wantStage          int
+		wantConfig         Config
+		wantStageRemaining time.Duration
+		wantRemaining      time.Duration
+	}{
+		{"start of ramp", ramp, 0, true, 0, first, time.Minute, 3 * time.Minute},
+		{"within first stage", ramp, 20 * time.Second, true, 0, first, 40 * time.Second, 160 * time.Second},
+		{"start of second stage", ramp, time.Minute, true, 1, second, 2 * time.Minute, 2 * time.Minute},
+		{"end of second stage", ramp, 3*time.Minute - time.Second, true, 1, second, time.Second, time.Second},
+		{"reverted after ramp", ramp, 3 * time.Minute, false, 0, Config{}, 0, 0},
+		{"long after ramp", ramp, time.Hour, false, 0, Config{}, 0, 0},
+		{"until disabled", []Stage{{Config: first}}, 24 * time.Hour, true, 0, first, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector := NewInjector()
+			injector.EnableSchedule(tt.stages)
+
+			got := injector.statusAt(injector.startedAt.Add(tt.elapsed))
+			if got.Enabled != tt.wantEnabled || got.Stage != tt.wantStage || got.Config != tt.wantConfig {
+				t.Errorf("statusAt() = enabled %v, stage %d, config %+v, want enabled %v, stage %d, config %+v",
+					got.Enabled, got.Stage, got.Config, tt.wantEnabled, tt.wantStage, tt.wantConfig)
+			}
+			if got.StageRemaining != tt.wantStageRemaining || got.Remaining != tt.wantRemaining {
+				t.Errorf("statusAt() remaining = %v of stage, %v in total, want %v of stage, %v in total",
+					got.StageRemaining, got.Remaining, tt.wantStageRemaining, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestInjectorReverts(t *testing.T) {
+	injector := NewInjector()
+	injector.Enable(Config{Error500Percent: 100}, 20*time.Millisecond)
+
+	if got, _ := injector.Inject(context.Background(), Target{}); got != Error500 {
+		t.Fatalf("Inject() = %v while enabled, want %v", got, Error500)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	if injector.IsEnabled() {
+		t.Error("IsEnabled() = true after the duration, want false")
+	}
+	if got, _ := injector.Inject(context.Background(), Target{}); got != None {
+		t.Errorf("Inject() = %v after the duration, want %v", got, None)
+	}
+}
+
+func TestInjectorDisable(t *testing.T) {
+	injector := NewInjector()
+	injector.Enable(Config{Error500Percent: 100}, 0)
+	injector.Disable()
+
+	if status := injector.Status(); status.Enabled || status.Stages != nil {
+		t.Errorf("Status() = %+v after Disable, want disabled", status)
+	}
+}
@@ -0,0 +1,109 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Latency distributions
+const (
+	DistributionFixed       = "fixed"
+	DistributionUniform     = "uniform"
+	DistributionNormal      = "normal"
+	DistributionExponential = "exponential"
+	DistributionPareto      = "pareto"
+)
+
+// maxLatencyMs caps injected delays at 5 minutes
+const maxLatencyMs = 300_000
+
+// Latency describes the delay injected into requests, in milliseconds
+// Which parameters apply depends on the distribution:
+//   - fixed: FixedMs
+//   - uniform: MinMs to MaxMs
+//   - normal: MeanMs and StddevMs, never below zero
+//   - exponential: MeanMs
+//   - pareto: MinMs as the scale and Alpha as the shape; lower alphas give longer tails
+//
+// MaxMs, when set, caps the delays of the unbounded distributions
+type Latency struct {
+	Distribution string
+	// Percent is the chance of delaying a request at all
+	Percent  float64
+	FixedMs  int
+	MinMs    int
+	MaxMs    int
+	MeanMs   int
+	StddevMs int
+	Alpha    float64
+}
+
+// Validate reports whether the parameters fit the distribution
+func (l Latency) Validate() error {
+	if l.Percent < 0 || l.Percent > 100 {
+		return errors.New("latency percent must be between 0 and 100")
+	}
+	if l.Percent == 0 {
+		return nil
+	}
+	if l.MaxMs < 0 || l.MaxMs > maxLatencyMs {
+		return fmt.Errorf("latency max_ms must be between 0 and %d", maxLatencyMs)
+	}
+
+	switch l.Distribution {
+	case DistributionFixed:
+		if l.FixedMs <= 0 || l.FixedMs > maxLatencyMs {
+			return fmt.Errorf("fixed latency needs fixed_ms between 1 and %d", maxLatencyMs)
+		}
+	case DistributionUniform:
+		if l.MinMs < 0 || l.MaxMs <= 0 || l.MinMs > l.MaxMs {
+			return errors.New("uniform latency needs 0 <= min_ms <= max_ms and a positive max_ms")
+		}
+	case DistributionNormal:
+		if l.MeanMs <= 0 || l.StddevMs < 0 {
+			return errors.New("normal latency needs a positive mean_ms and a non-negative stddev_ms")
+		}
+	case DistributionExponential:
+		if l.MeanMs <= 0 {
+			return errors.New("exponential latency needs a positive mean_ms")
+		}
+	case DistributionPareto:
+		if l.MinMs <= 0 || l.Alpha <= 0 {
+			return errors.New("pareto latency needs a positive min_ms and alpha")
+		}
+	default:
+		return fmt.Errorf("unknown latency distribution %q", l.Distribution)
+	}
+	return nil
+}
+
+// sample draws the delay of a single request, zero if it is not delayed
+func (l Latency) sample() time.Duration {
+	if l.Percent <= 0 || rand.Float64()*100 >= l.Percent {
+		return 0
+	}
+
+	var ms float64
+	switch l.Distribution {
+	case DistributionFixed:
+		ms = float64(l.FixedMs)
+	case DistributionUniform:
+		ms = float64(l.MinMs) + rand.Float64()*float64(l.MaxMs-l.MinMs)
+	case DistributionNormal:
+		ms = math.Max(0, float64(l.MeanMs)+rand.NormFloat64()*float64(l.StddevMs))
+	case DistributionExponential:
+		ms = rand.ExpFloat64() * float64(l.MeanMs)
+	case DistributionPareto:
+		// Inverse transform sampling; 1-Float64 is in (0, 1] so the power is finite
+		ms = float64(l.MinMs) / math.Pow(1-rand.Float64(), 1/l.Alpha)
+	}
+
+	limit := float64(maxLatencyMs)
+	if l.MaxMs > 0 {
+		limit = float64(l.MaxMs)
+	}
+	return time.Duration(math.Min(ms, limit) * float64(time.Millisecond))
+}
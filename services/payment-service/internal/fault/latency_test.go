@@ -0,0 +1,152 @@
+package fault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency Latency
+		wantErr bool
+	}{
+		{"disabled", Latency{}, false},
+		{"disabled ignores parameters", Latency{Distribution: "unknown"}, false},
+		{"negative percent", Latency{Percent: -1}, true},
+		{"percent above 100", Latency{Percent: 101}, true},
+		{"unknown distribution", Latency{Distribution: "unknown", Percent: 50}, true},
+		{"max above cap", Latency{Distribution: DistributionExponential, Percent: 50, MeanMs: 100, MaxMs: maxLatencyMs + 1}, true},
+		{"fixed", Latency{Distribution: DistributionFixed, Percent: 50, FixedMs: 100}, false},
+		{"fixed without delay", Latency{Distribution: DistributionFixed, Percent: 50}, true},
+		{"fixed above cap", Latency{Distribution: DistributionFixed, Percent: 50, FixedMs: maxLatencyMs + 1}, true},
+		{"uniform", Latency{Distribution: DistributionUniform, Percent: 50, MinMs: 100, MaxMs: 200}, false},
+		{"uniform from zero", Latency{Distribution: DistributionUniform, Percent: 50, MaxMs: 200}, false},
+		{"uniform min above max", Latency{Distribution: DistributionUniform, Percent: 50, MinMs: 300, MaxMs: 200}, true},
+		{"uniform without max", Latency{Distribution: DistributionUniform, Percent: 50, MinMs: 100}, true},
+		{"normal", Latency{Distribution: DistributionNormal, Percent: 50, MeanMs: 100, StddevMs: 20}, false},
+		{"normal without spread", Latency{Distribution: DistributionNormal, Percent: 50, MeanMs: 100}, false},
+		{"normal negative stddev", Latency{Distribution: DistributionNormal, Percent: 50, MeanMs: 100, StddevMs: -20}, true},
+		{"normal without mean", Latency{Distribution: DistributionNormal, Percent: 50, StddevMs: 20}, true},
+		{"exponential", Latency{Distribution: DistributionExponential, Percent: 50, MeanMs: 100}, false},
+		{"exponential without mean", Latency{Distribution: DistributionExponential, Percent: 50}, true},
+		{"pareto", Latency{Distribution: DistributionPareto, Percent: 50, MinMs: 100, Alpha: 1.5}, false},
+		{"pareto zero alpha", Latency{Distribution: DistributionPareto, Percent: 50, MinMs: 100}, true},
+		{"pareto negative alpha", Latency{Distribution: DistributionPareto, Percent: 50, MinMs: 100, Alpha: -1}, true},
+		{"pareto without scale", Latency{Distribution: DistributionPareto, Percent: 50, Alpha: 1.5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.latency.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLatencySample(t *testing.T) {
+	const samples = 2000
+
+	tests := []struct {
+		name     string
+		latency  Latency
+		min, max time.Duration
+		// mean, when set, is the expected average within 10%
+		mean time.Duration
+	}{
+		{
+			name:    "disabled",
+			latency: Latency{Distribution: DistributionFixed, FixedMs: 100},
+			max:     0,
+		},
+		{
+			name:    "fixed",
+			latency: Latency{Distribution: DistributionFixed, Percent: 100, FixedMs: 100},
+			min:     100 * time.Millisecond,
+			max:     100 * time.Millisecond,
+		},
+		{
+			name:    "uniform",
+			latency: Latency{Distribution: DistributionUniform, Percent: 100, MinMs: 100, MaxMs: 200},
+			min:     100 * time.Millisecond,
+			max:     200 * time.Millisecond,
+			mean:    150 * time.Millisecond,
+		},
+		{
+			name:    "normal never below zero",
+			latency: Latency{Distribution: DistributionNormal, Percent: 100, MeanMs: 10, StddevMs: 100},
+			min:     0,
+			max:     maxLatencyMs * time.Millisecond,
+		},
+		{
+			name:    "normal",
+			latency: Latency{Distribution: DistributionNormal, Percent: 100, MeanMs: 1000, StddevMs: 100},
+			min:     0,
+			max:     maxLatencyMs * time.Millisecond,
+			mean:    time.Second,
+		},
+		{
+			name:    "exponential",
+			latency: Latency{Distribution: DistributionExponential, Percent: 100, MeanMs: 100},
+			min:     0,
+			max:     maxLatencyMs * time.Millisecond,
+			mean:    100 * time.Millisecond,
+		},
+		{
+			name:    "exponential capped by max",
+			latency: Latency{Distribution: DistributionExponential, Percent: 100, MeanMs: 1000, MaxMs: 50},
+			min:     0,
+			max:     50 * time.Millisecond,
+		},
+		{
+			name:    "pareto",
+			latency: Latency{Distribution: DistributionPareto, Percent: 100, MinMs: 100, Alpha: 3},
+			min:     100 * time.Millisecond,
+			max:     maxLatencyMs * time.Millisecond,
+			mean:    150 * time.Millisecond,
+		},
+		{
+			name:    "pareto capped by max",
+			latency: Latency{Distribution: DistributionPareto, Percent: 100, MinMs: 100, Alpha: 0.1, MaxMs: 500},
+			min:     100 * time.Millisecond,
+			max:     500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var total time.Duration
+			for range samples {
+				d := tt.latency.sample()
+				if d < tt.min || d > tt.max {
+					t.Fatalf("sample() = %v, want between %v and %v", d, tt.min, tt.max)
+				}
+				total += d
+			}
+
+			if tt.mean > 0 {
+				mean := total / samples
+				if mean < tt.mean*9/10 || mean > tt.mean*11/10 {
+					t.Errorf("mean of samples = %v, want about %v", mean, tt.mean)
+				}
+			}
+		})
+	}
+}
+
+func TestLatencySamplePercent(t *testing.T) {
+	const samples = 10000
+
+	latency := Latency{Distribution: DistributionFixed, Percent: 25, FixedMs: 1}
+	delayed := 0
+	for range samples {
+		if latency.sample() > 0 {
+			delayed++
+		}
+	}
+
+	if share := float64(delayed) / samples * 100; share < 22 || share > 28 {
+		t.Errorf("delayed %v%% of requests, want about 25%%", share)
+	}
+}
@@ -0,0 +1,82 @@
+package fault
+
+import (
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Target describes the request faults may be injected into
+type Target struct {
+	Method        string
+	Path          string
+	Header        http.Header
+	PaymentMethod string
+	OrderID       string
+}
+
+// Match selects the requests faults are injected into
+// Every condition that is set must hold; an empty Match selects every request
+type Match struct {
+	// Method is the HTTP method
+	Method string
+	// Path is the exact request path, or a prefix when it ends with *
+	Path string
+	// Header must be present, with HeaderValue among its values when that is set
+	Header      string
+	HeaderValue string
+	// PaymentMethod is the method of the payment, such as credit_card
+	PaymentMethod string
+	// OrderPercent, when above 0, selects that share of order IDs by hash,
+	// so the same orders are hit on every attempt
+	OrderPercent float64
+}
+
+// Validate reports whether the conditions are consistent
+func (m Match) Validate() error {
+	if m.OrderPercent < 0 || m.OrderPercent > 100 {
+		return errors.New("match order percent must be between 0 and 100")
+	}
+	if m.HeaderValue != "" && m.Header == "" {
+		return errors.New("match header value needs a header name")
+	}
+	return nil
+}
+
+// Matches reports whether t meets every condition of m
+func (m Match) Matches(t Target) bool {
+	if m.Method != "" && !strings.EqualFold(m.Method, t.Method) {
+		return false
+	}
+	if m.Path != "" {
+		if prefix, ok := strings.CutSuffix(m.Path, "*"); ok {
+			if !strings.HasPrefix(t.Path, prefix) {
+				return false
+			}
+		} else if m.Path != t.Path {
+			return false
+		}
+	}
+	if m.Header != "" {
+		values := t.Header.Values(m.Header)
+		if len(values) == 0 || (m.HeaderValue != "" && !slices.Contains(values, m.HeaderValue)) {
+			return false
+		}
+	}
+	if m.PaymentMethod != "" && m.PaymentMethod != t.PaymentMethod {
+		return false
+	}
+	if m.OrderPercent > 0 && orderBucket(t.OrderID) >= m.OrderPercent {
+		return false
+	}
+	return true
+}
+
+// orderBucket maps an order ID to a stable point in [0, 100)
+func orderBucket(orderID string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(orderID))
+	return float64(h.Sum32()%10000) / 100
+}
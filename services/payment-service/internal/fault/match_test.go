@@ -0,0 +1,110 @@
+package fault
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestMatchMatches(t *testing.T) {
+	header := http.Header{}
+	header.Add("X-Chaos-Target", "canary")
+	header.Add("X-Chaos-Target", "true")
+
+	target := Target{
+		Method:        http.MethodPost,
+		Path:          "/api/payments",
+		Header:        header,
+		PaymentMethod: "paypal",
+		OrderID:       "order-1",
+	}
+
+	tests := []struct {
+		name  string
+		match Match
+		want  bool
+	}{
+		{"empty match", Match{}, true},
+		{"method ignores case", Match{Method: "post"}, true},
+		{"other method", Match{Method: http.MethodGet}, false},
+		{"exact path", Match{Path: "/api/payments"}, true},
+		{"exact path is not a prefix", Match{Path: "/api"}, false},
+		{"path prefix", Match{Path: "/api/*"}, true},
+		{"other path prefix", Match{Path: "/health/*"}, false},
+		{"header present", Match{Header: "X-Chaos-Target"}, true},
+		{"header missing", Match{Header: "X-Other"}, false},
+		{"first header value", Match{Header: "X-Chaos-Target", HeaderValue: "canary"}, true},
+		{"later header value", Match{Header: "X-Chaos-Target", HeaderValue: "true"}, true},
+		{"other header value", Match{Header: "X-Chaos-Target", HeaderValue: "false"}, false},
+		{"payment method", Match{PaymentMethod: "paypal"}, true},
+		{"other payment method", Match{PaymentMethod: "credit_card"}, false},
+		{"every order", Match{OrderPercent: 100}, true},
+		{"every condition", Match{Method: "POST", Path: "/api/*", Header: "X-Chaos-Target", HeaderValue: "true", PaymentMethod: "paypal", OrderPercent: 100}, true},
+		{"one condition fails", Match{Method: "POST", Path: "/api/*", PaymentMethod: "debit_card"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.Matches(target); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchOrderPercent(t *testing.T) {
+	tests := []struct {
+		percent float64
+	}{
+		{10},
+		{25},
+		{50},
+		{90},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v%%", tt.percent), func(t *testing.T) {
+			m := Match{OrderPercent: tt.percent}
+			matched := 0
+			for i := range 10000 {
+				target := Target{OrderID: fmt.Sprintf("order-%d", i)}
+				got := m.Matches(target)
+				if got != (orderBucket(target.OrderID) < tt.percent) {
+					t.Fatalf("Matches(%s) = %v, inconsistent with bucket %v", target.OrderID, got, orderBucket(target.OrderID))
+				}
+				if m.Matches(target) != got {
+					t.Fatalf("Matches(%s) is not sticky", target.OrderID)
+				}
+				if got {
+					matched++
+				}
+			}
+			if share := float64(matched) / 100; share < tt.percent-3 || share > tt.percent+3 {
+				t.Errorf("matched %v%% of orders, want about %v%%", share, tt.percent)
+			}
+		})
+	}
+}
+
+func TestMatchValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   Match
+		wantErr bool
+	}{
+		{"empty", Match{}, false},
+		{"header value with header", Match{Header: "X-Chaos-Target", HeaderValue: "true"}, false},
+		{"header value without header", Match{HeaderValue: "true"}, true},
+		{"order percent bounds", Match{OrderPercent: 100}, false},
+		{"negative order percent", Match{OrderPercent: -1}, true},
+		{"order percent above 100", Match{OrderPercent: 101}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.match.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/LuoZihYuan/go-down/services/payment-service/internal/fault"
 	"github.com/LuoZihYuan/go-down/services/payment-service/internal/models"
@@ -24,7 +26,9 @@ func NewChaosHandler(injector *fault.Injector) *ChaosHandler {
 
 // EnableChaos enables fault injection
 // @Summary Enable chaos injection
-// @Description Enables fault injection with specified delay
+// @Description Enables fault injection with the specified latency distribution and error, abort and malformed response percentages,
+// @Description limited to the payment requests that meet the match conditions
+// @Description Injection reverts after duration, or steps through stages and reverts after the last one
 // @Tags Chaos
 // @Accept json
 // @Produce json
@@ -43,12 +47,23 @@ func (h *ChaosHandler) EnableChaos(c *gin.Context) {
 		return
 	}
 
-	h.faultInjector.Enable(req.DelaySeconds)
+	stages, err := toStages(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Title:  "Bad Request",
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf("Invalid chaos configuration: %v", err),
+		})
+		return
+	}
 
-	c.JSON(http.StatusOK, models.ChaosStatus{
-		Enabled:      true,
-		DelaySeconds: req.DelaySeconds,
-	})
+	if len(req.Stages) == 0 {
+		h.faultInjector.Enable(stages[0].Config, stages[0].Duration)
+	} else {
+		h.faultInjector.EnableSchedule(stages)
+	}
+
+	c.JSON(http.StatusOK, toChaosStatus(h.faultInjector.Status()))
 }
 
 // DisableChaos disables fault injection
@@ -61,24 +76,146 @@ func (h *ChaosHandler) EnableChaos(c *gin.Context) {
 func (h *ChaosHandler) DisableChaos(c *gin.Context) {
 	h.faultInjector.Disable()
 
-	c.JSON(http.StatusOK, models.ChaosStatus{
-		Enabled:      false,
-		DelaySeconds: 0,
-	})
+	c.JSON(http.StatusOK, toChaosStatus(fault.Status{}))
 }
 
 // GetChaosStatus returns current chaos injection status
 // @Summary Get chaos status
-// @Description Returns current fault injection configuration
+// @Description Returns current fault injection configuration, the current stage and the time until it reverts
 // @Tags Chaos
 // @Produce json
 // @Success 200 {object} models.ChaosStatus
 // @Router /chaos/status [get]
 func (h *ChaosHandler) GetChaosStatus(c *gin.Context) {
-	enabled, delay := h.faultInjector.GetStatus()
+	c.JSON(http.StatusOK, toChaosStatus(h.faultInjector.Status()))
+}
+
+// toStages converts a chaos request to the stages of its schedule
+// A request without stages is a single stage lasting its duration, or until disabled
+func toStages(req models.ChaosRequest) ([]fault.Stage, error) {
+	if req.DelaySeconds > 0 {
+		// delay_seconds predates latency distributions and delayed every request
+		if len(req.Stages) > 0 || req.Latency != (models.ChaosLatency{}) {
+			return nil, errors.New("delay_seconds cannot be combined with latency or stages")
+		}
+		req.Latency = models.ChaosLatency{
+			Distribution: fault.DistributionFixed,
+			Percent:      100,
+			FixedMs:      req.DelaySeconds * 1000,
+		}
+	}
+
+	if len(req.Stages) == 0 {
+		var duration time.Duration
+		if req.Duration != "" {
+			d, err := time.ParseDuration(req.Duration)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid duration %q", req.Duration)
+			}
+			duration = d
+		}
+		cfg := toFaultConfig(req.Match, req.ChaosFaults)
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+		return []fault.Stage{{Config: cfg, Duration: duration}}, nil
+	}
 
-	c.JSON(http.StatusOK, models.ChaosStatus{
-		Enabled:      enabled,
-		DelaySeconds: delay,
-	})
+	if req.Duration != "" || req.ChaosFaults != (models.ChaosFaults{}) {
+		return nil, errors.New("stages cannot be combined with a duration or top-level faults")
+	}
+	stages := make([]fault.Stage, 0, len(req.Stages))
+	for n, s := range req.Stages {
+		d, err := time.ParseDuration(s.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d: invalid duration %q", n+1, s.Duration)
+		}
+		stage := fault.Stage{Config: toFaultConfig(req.Match, s.ChaosFaults), Duration: d}
+		if err := stage.Validate(); err != nil {
+			return nil, fmt.Errorf("stage %d: %w", n+1, err)
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+// toFaultConfig converts the API model of a match and its faults to a fault configuration
+func toFaultConfig(match models.ChaosMatch, faults models.ChaosFaults) fault.Config {
+	return fault.Config{
+		Match: fault.Match{
+			Method:        match.Method,
+			Path:          match.Path,
+			Header:        match.Header,
+			HeaderValue:   match.HeaderValue,
+			PaymentMethod: match.PaymentMethod,
+			OrderPercent:  match.OrderPercent,
+		},
+		Latency: fault.Latency{
+			Distribution: faults.Latency.Distribution,
+			Percent:      faults.Latency.Percent,
+			FixedMs:      faults.Latency.FixedMs,
+			MinMs:        faults.Latency.MinMs,
+			MaxMs:        faults.Latency.MaxMs,
+			MeanMs:       faults.Latency.MeanMs,
+			StddevMs:     faults.Latency.StddevMs,
+			Alpha:        faults.Latency.Alpha,
+		},
+		Error500Percent:  faults.Error500Percent,
+		Error503Percent:  faults.Error503Percent,
+		Error429Percent:  faults.Error429Percent,
+		AbortPercent:     faults.AbortPercent,
+		MalformedPercent: faults.MalformedPercent,
+	}
+}
+
+// toChaosFaults converts the faults of a configuration to their API model
+func toChaosFaults(cfg fault.Config) models.ChaosFaults {
+	return models.ChaosFaults{
+		Latency: models.ChaosLatency{
+			Distribution: cfg.Latency.Distribution,
+			Percent:      cfg.Latency.Percent,
+			FixedMs:      cfg.Latency.FixedMs,
+			MinMs:        cfg.Latency.MinMs,
+			MaxMs:        cfg.Latency.MaxMs,
+			MeanMs:       cfg.Latency.MeanMs,
+			StddevMs:     cfg.Latency.StddevMs,
+			Alpha:        cfg.Latency.Alpha,
+		},
+		Error500Percent:  cfg.Error500Percent,
+		Error503Percent:  cfg.Error503Percent,
+		Error429Percent:  cfg.Error429Percent,
+		AbortPercent:     cfg.AbortPercent,
+		MalformedPercent: cfg.MalformedPercent,
+	}
+}
+
+// toChaosStatus converts the fault injection in effect to its API model
+func toChaosStatus(status fault.Status) models.ChaosStatus {
+	result := models.ChaosStatus{
+		Enabled: status.Enabled,
+		Match: models.ChaosMatch{
+			Method:        status.Config.Match.Method,
+			Path:          status.Config.Match.Path,
+			Header:        status.Config.Match.Header,
+			HeaderValue:   status.Config.Match.HeaderValue,
+			PaymentMethod: status.Config.Match.PaymentMethod,
+			OrderPercent:  status.Config.Match.OrderPercent,
+		},
+		ChaosFaults:           toChaosFaults(status.Config),
+		StageRemainingSeconds: status.StageRemaining.Seconds(),
+		RemainingSeconds:      status.Remaining.Seconds(),
+	}
+	if !status.Enabled {
+		return result
+	}
+
+	result.CurrentStage = status.Stage + 1
+	for _, s := range status.Stages {
+		stage := models.ChaosStage{ChaosFaults: toChaosFaults(s.Config)}
+		if s.Duration > 0 {
+			stage.Duration = s.Duration.String()
+		}
+		result.Stages = append(result.Stages, stage)
+	}
+	return result
 }
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/LuoZihYuan/go-down/services/payment-service/internal/fault"
+	"github.com/LuoZihYuan/go-down/services/payment-service/internal/models"
+)
+
+func TestToStagesDelaySeconds(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.ChaosRequest
+		want    fault.Latency
+		wantErr bool
+	}{
+		{
+			name: "maps to fixed latency on every request",
+			req:  models.ChaosRequest{DelaySeconds: 2},
+			want: fault.Latency{Distribution: fault.DistributionFixed, Percent: 100, FixedMs: 2000},
+		},
+		{
+			name:    "rejects latency",
+			req:     models.ChaosRequest{DelaySeconds: 2, ChaosFaults: models.ChaosFaults{Latency: models.ChaosLatency{Percent: 50}}},
+			wantErr: true,
+		},
+		{
+			name:    "rejects stages",
+			req:     models.ChaosRequest{DelaySeconds: 2, Stages: []models.ChaosStage{{Duration: "1m"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, err := toStages(tt.req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("toStages() = %+v, want error", stages)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("toStages() error = %v", err)
+			}
+			if len(stages) != 1 || stages[0].Config.Latency != tt.want || stages[0].Duration != 0 {
+				t.Errorf("toStages() = %+v, want one unbounded stage with latency %+v", stages, tt.want)
+			}
+		})
+	}
+}
+
+func TestToStages(t *testing.T) {
+	match := models.ChaosMatch{Method: "POST", OrderPercent: 25}
+	faultMatch := fault.Match{Method: "POST", OrderPercent: 25}
+
+	tests := []struct {
+		name    string
+		req     models.ChaosRequest
+		want    []fault.Stage
+		wantErr bool
+	}{
+		{
+			name: "until disabled",
+			req:  models.ChaosRequest{Match: match, ChaosFaults: models.ChaosFaults{Error503Percent: 20}},
+			want: []fault.Stage{{Config: fault.Config{Match: faultMatch, Error503Percent: 20}}},
+		},
+		{
+			name: "with duration",
+			req:  models.ChaosRequest{Match: match, ChaosFaults: models.ChaosFaults{AbortPercent: 5}, Duration: "5m"},
+			want: []fault.Stage{{Config: fault.Config{Match: faultMatch, AbortPercent: 5}, Duration: 5 * time.Minute}},
+		},
+		{
+			name:    "invalid duration",
+			req:     models.ChaosRequest{Duration: "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "negative duration",
+			req:     models.ChaosRequest{Duration: "-1m"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid faults",
+			req:     models.ChaosRequest{ChaosFaults: models.ChaosFaults{Error500Percent: 60, Error503Percent: 60}},
+			wantErr: true,
+		},
+		{
+			name: "ramp shares the match",
+			req: models.ChaosRequest{Match: match, Stages: []models.ChaosStage{
+				{Duration: "1m", ChaosFaults: models.ChaosFaults{Error500Percent: 10}},
+				{Duration: "2m", ChaosFaults: models.ChaosFaults{Error500Percent: 50}},
+			}},
+			want: []fault.Stage{
+				{Config: fault.Config{Match: faultMatch, Error500Percent: 10}, Duration: time.Minute},
+				{Config: fault.Config{Match: faultMatch, Error500Percent: 50}, Duration: 2 * time.Minute},
+			},
+		},
+		{
+			name: "stages with duration",
+			req: models.ChaosRequest{Duration: "5m", Stages: []models.ChaosStage{
+				{Duration: "1m", ChaosFaults: models.ChaosFaults{Error500Percent: 10}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "stages with top-level faults",
+			req: models.ChaosRequest{ChaosFaults: models.ChaosFaults{Error500Percent: 10}, Stages: []models.ChaosStage{
+				{Duration: "1m", ChaosFaults: models.ChaosFaults{Error500Percent: 10}},
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "stage without positive duration",
+			req:     models.ChaosRequest{Stages: []models.ChaosStage{{Duration: "0s"}}},
+			wantErr: true,
+		},
+		{
+			name:    "stage with invalid faults",
+			req:     models.ChaosRequest{Stages: []models.ChaosStage{{Duration: "1m", ChaosFaults: models.ChaosFaults{AbortPercent: 101}}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toStages(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toStages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toStages() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToChaosStatus(t *testing.T) {
+	latency := fault.Latency{Distribution: fault.DistributionPareto, Percent: 50, MinMs: 100, MaxMs: 10000, Alpha: 1.5}
+	ramp := []fault.Stage{
+		{Config: fault.Config{Match: fault.Match{Path: "/api/*"}, Error500Percent: 10}, Duration: time.Minute},
+		{Config: fault.Config{Match: fault.Match{Path: "/api/*"}, Latency: latency}, Duration: 90 * time.Second},
+	}
+
+	tests := []struct {
+		name   string
+		status fault.Status
+		want   models.ChaosStatus
+	}{
+		{
+			name:   "disabled",
+			status: fault.Status{},
+			want:   models.ChaosStatus{},
+		},
+		{
+			name:   "until disabled",
+			status: fault.Status{Enabled: true, Config: fault.Config{AbortPercent: 5}, Stages: []fault.Stage{{Config: fault.Config{AbortPercent: 5}}}},
+			want: models.ChaosStatus{
+				Enabled:      true,
+				ChaosFaults:  models.ChaosFaults{AbortPercent: 5},
+				CurrentStage: 1,
+				Stages:       []models.ChaosStage{{ChaosFaults: models.ChaosFaults{AbortPercent: 5}}},
+			},
+		},
+		{
+			name:   "second stage of ramp",
+			status: fault.Status{Enabled: true, Config: ramp[1].Config, Stages: ramp, Stage: 1, StageRemaining: 30 * time.Second, Remaining: 30 * time.Second},
+			want: models.ChaosStatus{
+				Enabled: true,
+				Match:   models.ChaosMatch{Path: "/api/*"},
+				ChaosFaults: models.ChaosFaults{Latency: models.ChaosLatency{
+					Distribution: fault.DistributionPareto, Percent: 50, MinMs: 100, MaxMs: 10000, Alpha: 1.5,
+				}},
+				CurrentStage:          2,
+				StageRemainingSeconds: 30,
+				RemainingSeconds:      30,
+				Stages: []models.ChaosStage{
+					{Duration: "1m0s", ChaosFaults: models.ChaosFaults{Error500Percent: 10}},
+					{Duration: "1m30s", ChaosFaults: models.ChaosFaults{Latency: models.ChaosLatency{
+						Distribution: fault.DistributionPareto, Percent: 50, MinMs: 100, MaxMs: 10000, Alpha: 1.5,
+					}}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toChaosStatus(tt.status); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toChaosStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
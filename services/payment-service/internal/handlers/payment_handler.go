@@ -1,17 +1,23 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/LuoZihYuan/go-down/services/payment-service/internal/fault"
 	"github.com/LuoZihYuan/go-down/services/payment-service/internal/models"
+	resiliencemw "github.com/LuoZihYuan/go-down/services/pkg/resilience/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// statusClientClosedRequest records requests whose caller went away before a response, as nginx does
+const statusClientClosedRequest = 499
+
 // PaymentHandler handles payment-related requests
 type PaymentHandler struct {
 	faultInjector *fault.Injector
@@ -31,9 +37,14 @@ func NewPaymentHandler(injector *fault.Injector) *PaymentHandler {
 // @Accept json
 // @Produce json
 // @Param payment body models.PaymentRequest true "Payment request"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe; repeats replay the first response"
 // @Success 200 {object} models.PaymentResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Failure 504 {object} models.ErrorResponse
 // @Router /api/payments [post]
 func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 	var req models.PaymentRequest
@@ -47,7 +58,32 @@ func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 	}
 
 	// Inject fault if chaos is enabled
-	h.faultInjector.Inject()
+	injected, err := h.faultInjector.Inject(c.Request.Context(), fault.Target{
+		Method:        c.Request.Method,
+		Path:          c.Request.URL.Path,
+		Header:        c.Request.Header,
+		PaymentMethod: req.Method,
+		OrderID:       req.OrderID,
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		c.JSON(http.StatusGatewayTimeout, models.ErrorResponse{
+			Title:  "Gateway Timeout",
+			Status: http.StatusGatewayTimeout,
+			Detail: "Request deadline exceeded during injected latency",
+		})
+		return
+	}
+	if err != nil {
+		// The caller is gone, nobody reads the response
+		c.AbortWithStatus(statusClientClosedRequest)
+		return
+	}
+	if injected != fault.None {
+		// Injected faults say nothing about the payment, so retries must not replay them
+		resiliencemw.DiscardResponse(c)
+		applyFault(c, injected)
+		return
+	}
 
 	// Generate mock payment response
 	response := models.PaymentResponse{
@@ -61,3 +97,30 @@ func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// applyFault answers the request with an injected failure
+func applyFault(c *gin.Context, injected fault.Fault) {
+	switch injected {
+	case fault.Abort:
+		// Drop the connection without a response, as a crashing server would
+		conn, _, err := c.Writer.Hijack()
+		if err != nil {
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		conn.Close()
+		c.Abort()
+	case fault.MalformedJSON:
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(`{"payment_id": "pay-`))
+	default:
+		status := injected.StatusCode()
+		if status == http.StatusServiceUnavailable || status == http.StatusTooManyRequests {
+			c.Header("Retry-After", "1")
+		}
+		c.JSON(status, models.ErrorResponse{
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: "Fault injected by chaos testing",
+		})
+	}
+}
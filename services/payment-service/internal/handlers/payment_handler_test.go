@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/payment-service/internal/fault"
+	"github.com/LuoZihYuan/go-down/services/payment-service/internal/middleware"
+	"github.com/LuoZihYuan/go-down/services/payment-service/internal/models"
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+	resiliencemw "github.com/LuoZihYuan/go-down/services/pkg/resilience/middleware"
+)
+
+func TestProcessPaymentInjectedFaultsNotReplayed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		cfg  fault.Config
+	}{
+		{"malformed json", fault.Config{MalformedPercent: 100}},
+		{"error 500", fault.Config{Error500Percent: 100}},
+		{"error 429", fault.Config{Error429Percent: 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector := fault.NewInjector()
+			handler := NewPaymentHandler(injector)
+			router := gin.New()
+			router.POST("/api/payments",
+				resiliencemw.IdempotencyMiddleware(resilience.NewIdempotencyStore("test-payments-"+tt.name, time.Minute), middleware.RenderError),
+				handler.ProcessPayment)
+
+			pay := func() *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/api/payments", strings.NewReader(`{"order_id":"order-1","amount":10,"method":"paypal"}`))
+				req.Header.Set(resilience.IdempotencyKeyHeader, "k1")
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				return w
+			}
+
+			injector.Enable(tt.cfg, 0)
+			pay()
+			injector.Disable()
+
+			w := pay()
+			var resp models.PaymentResponse
+			if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &resp) != nil || resp.PaymentID == "" {
+				t.Fatalf("retry after chaos = %d %q, want a processed payment", w.Code, w.Body.String())
+			}
+			if w.Header().Get("Idempotent-Replayed") != "" {
+				t.Fatal("retry replayed the injected fault")
+			}
+		})
+	}
+}
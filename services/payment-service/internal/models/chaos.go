@@ -1,14 +1,73 @@
 package models
 
 // ChaosRequest represents chaos injection configuration
-// @Description Chaos injection settings
+// @Description Chaos injection settings; fault percentages are independent of the latency and must not add up to more than 100.
+// @Description Faults revert after duration, or run until disabled without one. Alternatively stages ramp
+// @Description through several fault settings in order and revert after the last; they cannot be combined
+// @Description with duration or top-level faults. delay_seconds is deprecated in favour of a fixed latency
 type ChaosRequest struct {
-	DelaySeconds int `json:"delay_seconds" binding:"required,min=1,max=300" example:"30"`
+	Match ChaosMatch `json:"match"`
+	ChaosFaults
+	Duration     string       `json:"duration" example:"5m"`
+	Stages       []ChaosStage `json:"stages" binding:"dive"`
+	DelaySeconds int          `json:"delay_seconds,omitempty" binding:"min=0,max=300" example:"0"`
 } // @name ChaosRequest
 
+// ChaosFaults represents the faults injected into matching requests
+// @Description Injected latency and fault percentages
+type ChaosFaults struct {
+	Latency          ChaosLatency `json:"latency"`
+	Error500Percent  float64      `json:"error_500_percent" binding:"min=0,max=100" example:"10"`
+	Error503Percent  float64      `json:"error_503_percent" binding:"min=0,max=100" example:"5"`
+	Error429Percent  float64      `json:"error_429_percent" binding:"min=0,max=100" example:"0"`
+	AbortPercent     float64      `json:"abort_percent" binding:"min=0,max=100" example:"2"`
+	MalformedPercent float64      `json:"malformed_percent" binding:"min=0,max=100" example:"1"`
+} // @name ChaosFaults
+
+// ChaosStage represents one step of a chaos ramp
+// @Description Faults injected for the duration of a stage
+type ChaosStage struct {
+	Duration string `json:"duration" binding:"required" example:"1m"`
+	ChaosFaults
+} // @name ChaosStage
+
+// ChaosMatch represents the requests chaos is limited to
+// @Description Conditions a payment request must meet to have faults injected; unset conditions match every request.
+// @Description path matches exactly or as a prefix when it ends with *, header_value needs header,
+// @Description and order_percent selects a sticky share of order IDs by hash
+type ChaosMatch struct {
+	Method        string  `json:"method" example:"POST"`
+	Path          string  `json:"path" example:"/api/payments"`
+	Header        string  `json:"header" example:"X-Chaos-Target"`
+	HeaderValue   string  `json:"header_value" example:"true"`
+	PaymentMethod string  `json:"payment_method" binding:"omitempty,oneof=credit_card debit_card paypal" example:"paypal"`
+	OrderPercent  float64 `json:"order_percent" binding:"min=0,max=100" example:"25"`
+} // @name ChaosMatch
+
+// ChaosLatency represents the delay injected into requests
+// @Description Injected delay in milliseconds, drawn from a distribution:
+// @Description fixed (fixed_ms), uniform (min_ms to max_ms), normal (mean_ms, stddev_ms),
+// @Description exponential (mean_ms) or pareto (min_ms as scale, alpha as shape); max_ms caps the unbounded ones
+type ChaosLatency struct {
+	Distribution string  `json:"distribution" binding:"omitempty,oneof=fixed uniform normal exponential pareto" example:"pareto"`
+	Percent      float64 `json:"percent" binding:"min=0,max=100" example:"50"`
+	FixedMs      int     `json:"fixed_ms" binding:"min=0" example:"0"`
+	MinMs        int     `json:"min_ms" binding:"min=0" example:"100"`
+	MaxMs        int     `json:"max_ms" binding:"min=0" example:"10000"`
+	MeanMs       int     `json:"mean_ms" binding:"min=0" example:"0"`
+	StddevMs     int     `json:"stddev_ms" binding:"min=0" example:"0"`
+	Alpha        float64 `json:"alpha" binding:"min=0" example:"1.5"`
+} // @name ChaosLatency
+
 // ChaosStatus represents current chaos state
-// @Description Current chaos injection status
+// @Description Current chaos injection status; the faults are those of the current stage.
+// @Description current_stage counts from 1, and the remaining times are 0 when chaos runs until disabled
 type ChaosStatus struct {
-	Enabled      bool `json:"enabled" example:"true"`
-	DelaySeconds int  `json:"delay_seconds" example:"30"`
+	Enabled bool       `json:"enabled" example:"true"`
+	Match   ChaosMatch `json:"match"`
+	ChaosFaults
+	CurrentStage          int          `json:"current_stage" example:"2"`
+	StageRemainingSeconds float64      `json:"stage_remaining_seconds" example:"42.5"`
+	RemainingSeconds      float64      `json:"remaining_seconds" example:"102.5"`
+	Stages                []ChaosStage `json:"stages,omitempty"`
 } // @name ChaosStatus
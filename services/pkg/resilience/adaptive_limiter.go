@@ -0,0 +1,165 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var concurrencyLimit = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "adaptive_concurrency_limit",
+		Help: "Current concurrency limit of an adaptive limiter",
+	},
+	[]string{"pool"},
+)
+
+// ErrLimitExceeded is returned when an adaptive limiter is at its current limit
+// It wraps ErrBulkheadFull so callers can treat both limiters alike
+var ErrLimitExceeded = fmt.Errorf("%w: adaptive concurrency limit reached", ErrBulkheadFull)
+
+// AdaptiveLimiter bounds concurrent calls like a Bulkhead, but adjusts the
+// limit from the latency and failures it observes instead of using a fixed size
+// Calls beyond the current limit are rejected immediately
+// It reports bulkhead_active and bulkhead_rejected_total under the same pool label as a Bulkhead
+type AdaptiveLimiter struct {
+	mu            sync.Mutex
+	algorithm     limitAlgorithm
+	algorithmName string
+	limit         float64
+	minLimit      int
+	maxLimit      int
+	inFlight      int
+	poolName      string
+}
+
+// NewAdaptiveLimiter creates an adaptive limiter
+// Settings default to DefaultAdaptiveLimiterConfig and can be overridden with options
+// Returns an error if the resulting configuration is invalid, such as an unknown algorithm
+// The limiter registers itself with DefaultRegistry
+func NewAdaptiveLimiter(poolName string, opts ...AdaptiveLimiterOption) (*AdaptiveLimiter, error) {
+	cfg := DefaultAdaptiveLimiterConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("adaptive limiter %s: %w", poolName, err)
+	}
+	algorithm, err := newLimitAlgorithm(cfg.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("adaptive limiter %s: %w", poolName, err)
+	}
+
+	l := &AdaptiveLimiter{
+		algorithm:     algorithm,
+		algorithmName: cfg.Algorithm,
+		limit:         float64(cfg.InitialLimit),
+		minLimit:      cfg.MinLimit,
+		maxLimit:      cfg.MaxLimit,
+		poolName:      poolName,
+	}
+
+	// Initialize metrics so they show up in Grafana immediately
+	concurrencyLimit.WithLabelValues(poolName).Set(float64(l.permits()))
+	bulkheadActive.WithLabelValues(poolName).Set(0)
+	bulkheadRejected.WithLabelValues(poolName).Add(0)
+
+	DefaultRegistry.RegisterBulkhead(l)
+
+	return l, nil
+}
+
+// Execute runs fn if the number of calls in flight is below the current limit
+// Returns ErrLimitExceeded when at the limit, or the context error if ctx is already done
+// The duration and outcome of fn feed the limit algorithm
+func (l *AdaptiveLimiter) Execute(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	if l.inFlight >= l.permits() {
+		l.mu.Unlock()
+		bulkheadRejected.WithLabelValues(l.poolName).Inc()
+		return ErrLimitExceeded
+	}
+	l.inFlight++
+	inFlight := l.inFlight
+	l.mu.Unlock()
+	bulkheadActive.WithLabelValues(l.poolName).Inc()
+
+	start := time.Now()
+	err := fn()
+	l.complete(time.Since(start), inFlight, err)
+
+	return err
+}
+
+// Do runs fn with adaptive concurrency limiting
+// This allows the limiter to be used as a Policy
+func (l *AdaptiveLimiter) Do(ctx context.Context, fn func() error) error {
+	return l.Execute(ctx, fn)
+}
+
+// Name returns the pool name the limiter reports metrics under
+func (l *AdaptiveLimiter) Name() string {
+	return l.poolName
+}
+
+// Limit returns the current concurrency limit
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.permits()
+}
+
+// Snapshot returns the current limit and usage of the limiter
+func (l *AdaptiveLimiter) Snapshot() BulkheadSnapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limit := l.permits()
+	return BulkheadSnapshot{
+		Name:          l.poolName,
+		Algorithm:     l.algorithmName,
+		MaxConcurrent: limit,
+		Active:        l.inFlight,
+		Available:     max(limit-l.inFlight, 0),
+	}
+}
+
+// complete releases the slot of a finished call and updates the limit from its outcome
+func (l *AdaptiveLimiter) complete(rtt time.Duration, inFlight int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	bulkheadActive.WithLabelValues(l.poolName).Dec()
+
+	// Calls that never reached the service, like circuit breaker rejections or
+	// callers giving up, say nothing about its capacity
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	sample := limitSample{
+		rtt:      rtt,
+		inFlight: inFlight,
+		dropped:  err != nil && DefaultIsFailure(err),
+	}
+	limit := l.algorithm.update(l.limit, sample)
+	l.limit = math.Max(float64(l.minLimit), math.Min(float64(l.maxLimit), limit))
+	concurrencyLimit.WithLabelValues(l.poolName).Set(float64(l.permits()))
+}
+
+// permits returns the whole number of calls the current limit admits
+// Callers hold l.mu
+func (l *AdaptiveLimiter) permits() int {
+	return int(l.limit)
+}
@@ -0,0 +1,162 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestAdaptiveLimiterRejectsAtLimit(t *testing.T) {
+	l, err := NewAdaptiveLimiter("test-adaptive-full", WithInitialLimit(1), WithLimitBounds(1, 1))
+	if err != nil {
+		t.Fatalf("NewAdaptiveLimiter error = %v", err)
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- l.Execute(context.Background(), func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	err = l.Execute(context.Background(), func() error { return nil })
+	if !errors.Is(err, ErrLimitExceeded) || !errors.Is(err, ErrBulkheadFull) {
+		t.Fatalf("err = %v, want ErrLimitExceeded", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("first call err = %v", err)
+	}
+	if snap := l.Snapshot(); snap.Active != 0 || snap.Algorithm != LimitAlgorithmAIMD {
+		t.Fatalf("snapshot = %+v, want idle aimd limiter", snap)
+	}
+}
+
+func TestAdaptiveLimiterAIMD(t *testing.T) {
+	const name = "test-adaptive-aimd"
+	l, err := NewAdaptiveLimiter(name, WithInitialLimit(1), WithLimitBounds(1, 5))
+	if err != nil {
+		t.Fatalf("NewAdaptiveLimiter error = %v", err)
+	}
+
+	// Sequential calls keep one call in flight, which only justifies a limit up to 3
+	for i := 0; i < 10; i++ {
+		_ = l.Execute(context.Background(), func() error { return nil })
+	}
+	if got := l.Limit(); got != 3 {
+		t.Fatalf("Limit() after successes = %d, want 3", got)
+	}
+	if got := testutil.ToFloat64(concurrencyLimit.WithLabelValues(name)); got != 3 {
+		t.Fatalf("adaptive_concurrency_limit = %v, want 3", got)
+	}
+
+	_ = l.Execute(context.Background(), func() error { return errUpstream })
+	if got := l.Limit(); got != 2 {
+		t.Fatalf("Limit() after failure = %d, want 2", got)
+	}
+
+	// Rejections by an inner circuit breaker say nothing about capacity
+	_ = l.Execute(context.Background(), func() error { return ErrCircuitOpen })
+	if got := l.Limit(); got != 2 {
+		t.Fatalf("Limit() after circuit open = %d, want 2", got)
+	}
+}
+
+func TestLimitAlgorithmsBackOffWhenLatencyRises(t *testing.T) {
+	for _, name := range []string{LimitAlgorithmVegas, LimitAlgorithmGradient} {
+		t.Run(name, func(t *testing.T) {
+			algorithm, err := newLimitAlgorithm(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			limit := 20.0
+			for i := 0; i < 20; i++ {
+				limit = algorithm.update(limit, limitSample{rtt: 10 * time.Millisecond, inFlight: int(limit)})
+			}
+			if limit <= 20 {
+				t.Fatalf("limit at steady latency = %v, want above 20", limit)
+			}
+
+			grown := limit
+			for i := 0; i < 20; i++ {
+				limit = algorithm.update(limit, limitSample{rtt: 100 * time.Millisecond, inFlight: int(limit)})
+			}
+			if limit >= grown {
+				t.Fatalf("limit at 10x latency = %v, want below %v", limit, grown)
+			}
+		})
+	}
+}
+
+func TestLimitAlgorithmsIgnoreIdleCallers(t *testing.T) {
+	for _, name := range []string{LimitAlgorithmAIMD, LimitAlgorithmVegas, LimitAlgorithmGradient} {
+		algorithm, _ := newLimitAlgorithm(name)
+		limit := 20.0
+		for i := 0; i < 20; i++ {
+			limit = algorithm.update(limit, limitSample{rtt: 10 * time.Millisecond, inFlight: 1})
+		}
+		if limit != 20 {
+			t.Fatalf("%s: limit with one call in flight = %v, want 20", name, limit)
+		}
+	}
+}
+
+func TestNewConcurrencyLimiter(t *testing.T) {
+	fixed, err := NewConcurrencyLimiter("test-limiter-fixed", DefaultBulkheadConfig(), DefaultAdaptiveLimiterConfig())
+	if err != nil {
+		t.Fatalf("NewConcurrencyLimiter error = %v", err)
+	}
+	if _, ok := fixed.(*Bulkhead); !ok {
+		t.Fatalf("limiter = %T, want *Bulkhead", fixed)
+	}
+
+	adaptive := DefaultAdaptiveLimiterConfig()
+	adaptive.Enabled = true
+	adaptive.Algorithm = LimitAlgorithmGradient
+	limiter, err := NewConcurrencyLimiter("test-limiter-adaptive", DefaultBulkheadConfig(), adaptive)
+	if err != nil {
+		t.Fatalf("NewConcurrencyLimiter error = %v", err)
+	}
+	if _, ok := limiter.(*AdaptiveLimiter); !ok {
+		t.Fatalf("limiter = %T, want *AdaptiveLimiter", limiter)
+	}
+	if got := limiter.Snapshot().Algorithm; got != LimitAlgorithmGradient {
+		t.Fatalf("Algorithm = %q, want gradient", got)
+	}
+}
+
+func TestNewAdaptiveLimiterInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []AdaptiveLimiterOption
+	}{
+		{"unknown algorithm", []AdaptiveLimiterOption{WithLimitAlgorithm("fastest")}},
+		{"min limit below 1", []AdaptiveLimiterOption{WithLimitBounds(0, 10)}},
+		{"initial limit out of bounds", []AdaptiveLimiterOption{WithInitialLimit(20), WithLimitBounds(1, 10)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if l, err := NewAdaptiveLimiter("test-adaptive-invalid", tt.opts...); err == nil {
+				t.Fatalf("NewAdaptiveLimiter = %v, want error", l)
+			}
+		})
+	}
+
+	adaptive := DefaultAdaptiveLimiterConfig()
+	adaptive.Enabled = true
+	adaptive.Algorithm = "fastest"
+	if _, err := NewConcurrencyLimiter("test-limiter-invalid", DefaultBulkheadConfig(), adaptive); err == nil {
+		t.Fatal("NewConcurrencyLimiter with an unknown algorithm succeeded, want error")
+	}
+}
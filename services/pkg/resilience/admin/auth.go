@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenEnv is the environment variable holding the admin token
+// Services only serve the admin API when it is set
+const TokenEnv = "ADMIN_TOKEN"
+
+// TokenMiddleware admits requests carrying token as "Authorization: Bearer <token>"
+// and rejects the rest with 401
+func TokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+				Title:  "Unauthorized",
+				Status: http.StatusUnauthorized,
+				Detail: "A valid admin token is required",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,194 @@
+// Package admin provides the admin API shared by the go-down services to inspect
+// their resilience policies and steer circuit breakers during incidents
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+// Handler exposes the resilience registry for inspection and incident response
+type Handler struct {
+	registry *resilience.Registry
+}
+
+// NewHandler creates a new admin handler
+func NewHandler(registry *resilience.Registry) *Handler {
+	return &Handler{
+		registry: registry,
+	}
+}
+
+// Register adds the admin routes to group
+// Guard group with TokenMiddleware, as the routes can open any circuit breaker
+func (h *Handler) Register(group gin.IRoutes) {
+	group.GET("", h.GetResilienceStatus)
+	group.GET("/circuit-breakers/:name/events", h.GetCircuitBreakerEvents)
+	group.POST("/circuit-breakers/:name/force-open", h.ForceOpenCircuitBreaker)
+	group.POST("/circuit-breakers/:name/force-close", h.ForceCloseCircuitBreaker)
+	group.POST("/circuit-breakers/:name/reset", h.ResetCircuitBreaker)
+}
+
+// GetResilienceStatus lists every circuit breaker and bulkhead with live state
+// @Summary Get resilience status
+// @Description Lists all registered circuit breakers and bulkheads with their live state
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} admin.ResilienceStatus
+// @Failure 401 {object} admin.ErrorResponse
+// @Router /admin/resilience [get]
+func (h *Handler) GetResilienceStatus(c *gin.Context) {
+	status := ResilienceStatus{
+		CircuitBreakers: make([]CircuitBreakerStatus, 0),
+		Bulkheads:       make([]BulkheadStatus, 0),
+	}
+	for _, cb := range h.registry.CircuitBreakers() {
+		status.CircuitBreakers = append(status.CircuitBreakers, toCircuitBreakerStatus(cb.Snapshot()))
+	}
+	for _, b := range h.registry.Bulkheads() {
+		snapshot := b.Snapshot()
+		status.Bulkheads = append(status.Bulkheads, BulkheadStatus{
+			Name:          snapshot.Name,
+			Algorithm:     snapshot.Algorithm,
+			MaxConcurrent: snapshot.MaxConcurrent,
+			Active:        snapshot.Active,
+			Available:     snapshot.Available,
+			Queued:        snapshot.Queued,
+			MaxQueue:      snapshot.MaxQueue,
+		})
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// GetCircuitBreakerEvents returns the recent state transitions of a circuit breaker
+// @Summary Get circuit breaker events
+// @Description Returns the recent state transitions of a circuit breaker, oldest first
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param name path string true "Circuit breaker name"
+// @Success 200 {array} admin.CircuitBreakerEvent
+// @Failure 401 {object} admin.ErrorResponse
+// @Failure 404 {object} admin.ErrorResponse
+// @Router /admin/resilience/circuit-breakers/{name}/events [get]
+func (h *Handler) GetCircuitBreakerEvents(c *gin.Context) {
+	cb, ok := h.lookupCircuitBreaker(c)
+	if !ok {
+		return
+	}
+
+	events := make([]CircuitBreakerEvent, 0)
+	for _, event := range cb.Events() {
+		events = append(events, CircuitBreakerEvent{
+			From:   event.From.String(),
+			To:     event.To.String(),
+			Reason: event.Reason,
+			Time:   event.Time,
+		})
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// ForceOpenCircuitBreaker opens a circuit breaker until it is closed or reset
+// @Summary Force circuit breaker open
+// @Description Opens a circuit breaker and holds it open until it is forced closed or reset
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param name path string true "Circuit breaker name"
+// @Success 200 {object} admin.CircuitBreakerStatus
+// @Failure 401 {object} admin.ErrorResponse
+// @Failure 404 {object} admin.ErrorResponse
+// @Router /admin/resilience/circuit-breakers/{name}/force-open [post]
+func (h *Handler) ForceOpenCircuitBreaker(c *gin.Context) {
+	cb, ok := h.lookupCircuitBreaker(c)
+	if !ok {
+		return
+	}
+
+	cb.ForceOpen()
+
+	c.JSON(http.StatusOK, toCircuitBreakerStatus(cb.Snapshot()))
+}
+
+// ForceCloseCircuitBreaker closes a circuit breaker until it is opened or reset
+// @Summary Force circuit breaker closed
+// @Description Closes a circuit breaker and holds it closed until it is forced open or reset
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param name path string true "Circuit breaker name"
+// @Success 200 {object} admin.CircuitBreakerStatus
+// @Failure 401 {object} admin.ErrorResponse
+// @Failure 404 {object} admin.ErrorResponse
+// @Router /admin/resilience/circuit-breakers/{name}/force-close [post]
+func (h *Handler) ForceCloseCircuitBreaker(c *gin.Context) {
+	cb, ok := h.lookupCircuitBreaker(c)
+	if !ok {
+		return
+	}
+
+	cb.ForceClose()
+
+	c.JSON(http.StatusOK, toCircuitBreakerStatus(cb.Snapshot()))
+}
+
+// ResetCircuitBreaker clears a circuit breaker's forced state and failure history
+// @Summary Reset circuit breaker
+// @Description Clears any forced state and failure history, returning the circuit breaker to closed
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param name path string true "Circuit breaker name"
+// @Success 200 {object} admin.CircuitBreakerStatus
+// @Failure 401 {object} admin.ErrorResponse
+// @Failure 404 {object} admin.ErrorResponse
+// @Router /admin/resilience/circuit-breakers/{name}/reset [post]
+func (h *Handler) ResetCircuitBreaker(c *gin.Context) {
+	cb, ok := h.lookupCircuitBreaker(c)
+	if !ok {
+		return
+	}
+
+	cb.Reset()
+
+	c.JSON(http.StatusOK, toCircuitBreakerStatus(cb.Snapshot()))
+}
+
+// lookupCircuitBreaker finds the circuit breaker named in the path
+// Writes a 404 response and returns false if there is none
+func (h *Handler) lookupCircuitBreaker(c *gin.Context) (resilience.ManagedCircuitBreaker, bool) {
+	name := c.Param("name")
+
+	cb, ok := h.registry.CircuitBreaker(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Title:  "Not Found",
+			Status: http.StatusNotFound,
+			Detail: fmt.Sprintf("Circuit breaker %s not found", name),
+		})
+		return nil, false
+	}
+	return cb, true
+}
+
+// toCircuitBreakerStatus converts a circuit breaker snapshot to its API model
+func toCircuitBreakerStatus(snapshot resilience.CircuitBreakerSnapshot) CircuitBreakerStatus {
+	return CircuitBreakerStatus{
+		Name:                     snapshot.Name,
+		State:                    snapshot.State.String(),
+		Forced:                   snapshot.Forced,
+		RequestsInWindow:         snapshot.RequestsInWindow,
+		FailuresInWindow:         snapshot.FailuresInWindow,
+		SlowCallsInWindow:        snapshot.SlowCallsInWindow,
+		HalfOpenProbes:           snapshot.HalfOpenProbes,
+		TimeUntilHalfOpenSeconds: snapshot.TimeUntilHalfOpen.Seconds(),
+	}
+}
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+const testToken = "secret"
+
+// newRouter serves the admin API of a registry holding one circuit breaker
+func newRouter() (*gin.Engine, *resilience.CircuitBreaker[string]) {
+	gin.SetMode(gin.TestMode)
+	registry := resilience.NewRegistry()
+	cb := resilience.NewCircuitBreaker[string]("test-admin-payment")
+	registry.RegisterCircuitBreaker(cb)
+
+	router := gin.New()
+	NewHandler(registry).Register(router.Group("/admin/resilience", TokenMiddleware(testToken)))
+	return router, cb
+}
+
+func request(router http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTokenMiddleware(t *testing.T) {
+	router, cb := newRouter()
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong token", "guess", http.StatusUnauthorized},
+		{"valid token", testToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := request(router, http.MethodPost, "/admin/resilience/circuit-breakers/test-admin-payment/force-open", tt.token); w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+	if state := cb.Snapshot().State; state != resilience.StateOpen {
+		t.Fatalf("state = %v, want open only after the authorized request", state)
+	}
+}
+
+func TestTokenMiddlewareRejectsEverythingWithoutToken(t *testing.T) {
+	router := gin.New()
+	router.GET("/admin", TokenMiddleware(""), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	if w := request(router, http.MethodGet, "/admin", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	router, _ := newRouter()
+
+	w := request(router, http.MethodGet, "/admin/resilience", testToken)
+	var status ResilienceStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("status = %d %s, %v", w.Code, w.Body.String(), err)
+	}
+	if len(status.CircuitBreakers) != 1 || status.CircuitBreakers[0].Name != "test-admin-payment" {
+		t.Fatalf("circuit breakers = %+v, want test-admin-payment", status.CircuitBreakers)
+	}
+
+	if w := request(router, http.MethodPost, "/admin/resilience/circuit-breakers/unknown/reset", testToken); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown circuit breaker = %d, want 404", w.Code)
+	}
+}
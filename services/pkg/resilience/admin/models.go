@@ -0,0 +1,52 @@
+package admin
+
+import "time"
+
+// ResilienceStatus represents the live state of the service's resilience policies
+// @Description Circuit breaker and bulkhead status
+type ResilienceStatus struct {
+	CircuitBreakers []CircuitBreakerStatus `json:"circuit_breakers"`
+	Bulkheads       []BulkheadStatus       `json:"bulkheads"`
+} // @name ResilienceStatus
+
+// CircuitBreakerStatus represents the live state of a circuit breaker
+// @Description Circuit breaker state and sliding window counts
+type CircuitBreakerStatus struct {
+	Name                     string  `json:"name" example:"payment"`
+	State                    string  `json:"state" example:"open"`
+	Forced                   bool    `json:"forced" example:"false"`
+	RequestsInWindow         int     `json:"requests_in_window" example:"12"`
+	FailuresInWindow         int     `json:"failures_in_window" example:"5"`
+	SlowCallsInWindow        int     `json:"slow_calls_in_window" example:"0"`
+	HalfOpenProbes           int     `json:"half_open_probes" example:"0"`
+	TimeUntilHalfOpenSeconds float64 `json:"time_until_half_open_seconds" example:"12.5"`
+} // @name CircuitBreakerStatus
+
+// BulkheadStatus represents the live state of a bulkhead
+// @Description Bulkhead or adaptive limiter capacity and usage
+type BulkheadStatus struct {
+	Name          string `json:"name" example:"payment"`
+	Algorithm     string `json:"algorithm" example:"fixed"`
+	MaxConcurrent int    `json:"max_concurrent" example:"10"`
+	Active        int    `json:"active" example:"3"`
+	Available     int    `json:"available" example:"7"`
+	Queued        int    `json:"queued" example:"0"`
+	MaxQueue      int    `json:"max_queue" example:"20"`
+} // @name BulkheadStatus
+
+// CircuitBreakerEvent represents a circuit breaker state transition
+// @Description Circuit breaker state transition
+type CircuitBreakerEvent struct {
+	From   string    `json:"from" example:"closed"`
+	To     string    `json:"to" example:"open"`
+	Reason string    `json:"reason" example:"5 failures in window reached threshold 5"`
+	Time   time.Time `json:"time" example:"2025-01-15T10:30:00Z"`
+} // @name CircuitBreakerEvent
+
+// ErrorResponse represents an error response of the admin API
+// @Description Standard error response
+type ErrorResponse struct {
+	Title  string `json:"title" example:"Not Found"`
+	Status int    `json:"status" example:"404"`
+	Detail string `json:"detail" example:"Circuit breaker payment not found"`
+} // @name AdminErrorResponse
@@ -0,0 +1,247 @@
+package resilience
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	bulkheadActive = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bulkhead_active",
+			Help: "Current number of active requests in bulkhead",
+		},
+		[]string{"pool"},
+	)
+
+	bulkheadRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bulkhead_rejected_total",
+			Help: "Total number of requests rejected by bulkhead",
+		},
+		[]string{"pool"},
+	)
+
+	bulkheadQueueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bulkhead_queue_depth",
+			Help: "Current number of requests waiting for a bulkhead permit",
+		},
+		[]string{"pool"},
+	)
+
+	bulkheadWaitSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bulkhead_wait_seconds",
+			Help:    "Time requests spent waiting for a bulkhead permit",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		},
+		[]string{"pool"},
+	)
+)
+
+var (
+	ErrBulkheadFull = errors.New("bulkhead is full")
+	// ErrBulkheadTimeout is returned when a queued request waited MaxWait without getting a permit
+	// It wraps ErrBulkheadFull so callers can treat both alike
+	ErrBulkheadTimeout = fmt.Errorf("%w: timed out waiting for a permit", ErrBulkheadFull)
+)
+
+// Bulkhead implements the bulkhead pattern with an optional bounded FIFO wait queue
+// Without a queue, requests beyond capacity are rejected immediately
+type Bulkhead struct {
+	mu            sync.Mutex
+	active        int
+	maxConcurrent int
+	waiters       *list.List // of chan struct{}, closed when a permit is handed over
+	maxQueue      int
+	maxWait       time.Duration
+	poolName      string
+}
+
+// NewBulkhead creates a new bulkhead with the specified capacity
+// Options enable a wait queue; by default requests beyond capacity are rejected
+// The bulkhead registers itself with DefaultRegistry
+func NewBulkhead(poolName string, maxConcurrent int, opts ...BulkheadOption) *Bulkhead {
+	cfg := BulkheadConfig{MaxConcurrent: maxConcurrent}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	b := &Bulkhead{
+		maxConcurrent: cfg.MaxConcurrent,
+		waiters:       list.New(),
+		maxQueue:      cfg.MaxQueue,
+		maxWait:       cfg.MaxWait,
+		poolName:      poolName,
+	}
+
+	// Initialize metrics to 0 so they show up in Grafana immediately
+	bulkheadActive.WithLabelValues(poolName).Set(0)
+	bulkheadRejected.WithLabelValues(poolName).Add(0)
+	bulkheadQueueDepth.WithLabelValues(poolName).Set(0)
+
+	DefaultRegistry.RegisterBulkhead(b)
+
+	return b
+}
+
+// Execute runs the provided function with bulkhead protection
+// When the bulkhead is at capacity the request waits in the queue for up to MaxWait
+// Returns ErrBulkheadFull if the queue is full, ErrBulkheadTimeout if the wait expires,
+// or the context error if ctx is done first
+func (b *Bulkhead) Execute(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := b.acquire(ctx); err != nil {
+		return err
+	}
+	defer b.release()
+
+	// Execute the function
+	return fn()
+}
+
+// TryExecute attempts to execute without blocking
+// Returns ErrBulkheadFull immediately if at capacity
+func (b *Bulkhead) TryExecute(fn func() error) error {
+	b.mu.Lock()
+	if !b.hasFreePermit() {
+		b.mu.Unlock()
+		bulkheadRejected.WithLabelValues(b.poolName).Inc()
+		return ErrBulkheadFull
+	}
+	b.grant()
+	b.mu.Unlock()
+	defer b.release()
+
+	return fn()
+}
+
+// Do runs fn with bulkhead protection
+// This allows the bulkhead to be used as a Policy
+func (b *Bulkhead) Do(ctx context.Context, fn func() error) error {
+	return b.Execute(ctx, fn)
+}
+
+// Name returns the pool name the bulkhead reports metrics under
+func (b *Bulkhead) Name() string {
+	return b.poolName
+}
+
+// GetActiveCount returns the current number of active requests
+func (b *Bulkhead) GetActiveCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.active
+}
+
+// Snapshot returns the current capacity and usage of the bulkhead
+func (b *Bulkhead) Snapshot() BulkheadSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BulkheadSnapshot{
+		Name:          b.poolName,
+		Algorithm:     "fixed",
+		MaxConcurrent: b.maxConcurrent,
+		Active:        b.active,
+		Available:     b.maxConcurrent - b.active,
+		Queued:        b.waiters.Len(),
+		MaxQueue:      b.maxQueue,
+	}
+}
+
+// acquire obtains a permit, queueing for one if the bulkhead is at capacity
+func (b *Bulkhead) acquire(ctx context.Context) error {
+	start := time.Now()
+
+	b.mu.Lock()
+	if b.hasFreePermit() {
+		b.grant()
+		b.mu.Unlock()
+		bulkheadWaitSeconds.WithLabelValues(b.poolName).Observe(0)
+		return nil
+	}
+	if b.waiters.Len() >= b.maxQueue {
+		b.mu.Unlock()
+		bulkheadRejected.WithLabelValues(b.poolName).Inc()
+		return ErrBulkheadFull
+	}
+	ready := make(chan struct{})
+	elem := b.waiters.PushBack(ready)
+	bulkheadQueueDepth.WithLabelValues(b.poolName).Set(float64(b.waiters.Len()))
+	b.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if b.maxWait > 0 {
+		timer := time.NewTimer(b.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case <-ready:
+		bulkheadWaitSeconds.WithLabelValues(b.poolName).Observe(time.Since(start).Seconds())
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = ErrBulkheadTimeout
+	}
+
+	b.mu.Lock()
+	select {
+	case <-ready:
+		// A permit was handed over while giving up; pass it on
+		b.mu.Unlock()
+		b.release()
+	default:
+		b.waiters.Remove(elem)
+		bulkheadQueueDepth.WithLabelValues(b.poolName).Set(float64(b.waiters.Len()))
+		b.mu.Unlock()
+	}
+	if errors.Is(err, ErrBulkheadTimeout) {
+		bulkheadRejected.WithLabelValues(b.poolName).Inc()
+	}
+	return err
+}
+
+// release returns a permit, handing it straight to the longest waiting request if any
+func (b *Bulkhead) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if front := b.waiters.Front(); front != nil {
+		b.waiters.Remove(front)
+		bulkheadQueueDepth.WithLabelValues(b.poolName).Set(float64(b.waiters.Len()))
+		close(front.Value.(chan struct{}))
+		return
+	}
+
+	b.active--
+	bulkheadActive.WithLabelValues(b.poolName).Dec()
+}
+
+// hasFreePermit reports whether a request may start without queueing
+// Waiting requests go first so the queue stays FIFO
+// Callers hold b.mu
+func (b *Bulkhead) hasFreePermit() bool {
+	return b.active < b.maxConcurrent && b.waiters.Len() == 0
+}
+
+// grant takes a permit
+// Callers hold b.mu
+func (b *Bulkhead) grant() {
+	b.active++
+	bulkheadActive.WithLabelValues(b.poolName).Inc()
+}
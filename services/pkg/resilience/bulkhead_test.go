@@ -0,0 +1,137 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBulkheadRejectsWhenFull(t *testing.T) {
+	b := NewBulkhead("test-full", 1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- b.TryExecute(func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	if got := b.GetActiveCount(); got != 1 {
+		t.Fatalf("GetActiveCount() = %d, want 1", got)
+	}
+	if err := b.TryExecute(func() error { return nil }); !errors.Is(err, ErrBulkheadFull) {
+		t.Fatalf("TryExecute err = %v, want ErrBulkheadFull", err)
+	}
+	if err := b.Execute(context.Background(), func() error { return nil }); !errors.Is(err, ErrBulkheadFull) {
+		t.Fatalf("Execute err = %v, want ErrBulkheadFull", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("first call err = %v", err)
+	}
+	if got := b.GetActiveCount(); got != 0 {
+		t.Fatalf("GetActiveCount() = %d, want 0", got)
+	}
+}
+
+func TestBulkheadPropagatesError(t *testing.T) {
+	var p Policy = NewBulkhead("test-error", 2)
+
+	if p.Name() != "test-error" {
+		t.Fatalf("Name() = %q, want %q", p.Name(), "test-error")
+	}
+	if err := p.Do(context.Background(), func() error { return errUpstream }); !errors.Is(err, errUpstream) {
+		t.Fatalf("err = %v, want errUpstream", err)
+	}
+}
+
+// occupy holds all of a bulkhead's permits until the returned release func is called
+func occupy(t *testing.T, b *Bulkhead, n int) func() {
+	t.Helper()
+	release := make(chan struct{})
+	started := make(chan struct{}, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			_ = b.Execute(context.Background(), func() error {
+				started <- struct{}{}
+				<-release
+				return nil
+			})
+		}()
+	}
+	for i := 0; i < n; i++ {
+		<-started
+	}
+	return func() { close(release) }
+}
+
+func TestBulkheadQueueAbsorbsBurst(t *testing.T) {
+	b := NewBulkhead("test-queue", 1, WithMaxQueue(2), WithMaxWait(time.Second))
+	release := occupy(t, b, 1)
+
+	order := make(chan int, 2)
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- b.Execute(context.Background(), func() error {
+				order <- i
+				return nil
+			})
+		}()
+		// Wait until the request is queued so the FIFO order is deterministic
+		for b.Snapshot().Queued != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if err := b.Execute(context.Background(), func() error { return nil }); !errors.Is(err, ErrBulkheadFull) {
+		t.Fatalf("err with full queue = %v, want ErrBulkheadFull", err)
+	}
+
+	release()
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("queued request err = %v", err)
+		}
+	}
+	if first, second := <-order, <-order; first != 0 || second != 1 {
+		t.Fatalf("queued requests ran in order %d, %d, want 0, 1", first, second)
+	}
+	if snap := b.Snapshot(); snap.Active != 0 || snap.Queued != 0 {
+		t.Fatalf("snapshot = %+v, want idle", snap)
+	}
+}
+
+func TestBulkheadQueueMaxWait(t *testing.T) {
+	b := NewBulkhead("test-queue-timeout", 1, WithMaxQueue(1), WithMaxWait(10*time.Millisecond))
+	release := occupy(t, b, 1)
+	defer release()
+
+	err := b.Execute(context.Background(), func() error { return nil })
+	if !errors.Is(err, ErrBulkheadTimeout) || !errors.Is(err, ErrBulkheadFull) {
+		t.Fatalf("err = %v, want ErrBulkheadTimeout", err)
+	}
+	if got := b.Snapshot().Queued; got != 0 {
+		t.Fatalf("Queued = %d after timeout, want 0", got)
+	}
+}
+
+func TestBulkheadQueueHonorsContext(t *testing.T) {
+	b := NewBulkhead("test-queue-context", 1, WithMaxQueue(1))
+	release := occupy(t, b, 1)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := b.Execute(ctx, func() error { return nil }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,185 @@
+package resilience
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	cacheRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Total number of cache lookups by result (hit, miss, stale)",
+		},
+		[]string{"cache", "result"},
+	)
+
+	cacheEntries = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cache_entries",
+			Help: "Current number of entries in a cache",
+		},
+		[]string{"cache"},
+	)
+)
+
+// CacheHeader reports how a response was served, as one of the CacheStatus values
+const CacheHeader = "X-Cache"
+
+// CacheStatus tells how Fetch produced a value
+type CacheStatus string
+
+const (
+	// CacheHit is a fresh cached value
+	CacheHit CacheStatus = "HIT"
+	// CacheMiss is a value just fetched from the origin, or the origin's error
+	CacheMiss CacheStatus = "MISS"
+	// CacheStale is an expired cached value served because the origin failed
+	CacheStale CacheStatus = "STALE"
+)
+
+// cacheEntry is a cached value and when it was stored
+type cacheEntry[V any] struct {
+	key      string
+	value    V
+	storedAt time.Time
+}
+
+// Cache is an LRU cache of origin responses with stale-if-error semantics
+// Values are served from the cache for TTL; after that the origin is asked again,
+// and if it fails the expired value is served for up to MaxStale more
+type Cache[V any] struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element // of *cacheEntry[V]
+	lru        *list.List               // most recently used first
+	maxEntries int
+	ttl        time.Duration
+	maxStale   time.Duration
+	staleIf    func(error) bool
+	name       string
+}
+
+// NewCache creates a response cache
+// Settings default to DefaultCacheConfig and can be overridden with options
+func NewCache[V any](name string, opts ...CacheOption) *Cache[V] {
+	o := cacheOptions{
+		config:  DefaultCacheConfig(),
+		staleIf: DefaultIsFailure,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &Cache[V]{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: o.config.MaxEntries,
+		ttl:        o.config.TTL,
+		maxStale:   o.config.MaxStale,
+		staleIf:    o.staleIf,
+		name:       name,
+	}
+
+	// Initialize metrics to 0 so they show up in Grafana immediately
+	for _, status := range []CacheStatus{CacheHit, CacheMiss, CacheStale} {
+		cacheRequests.WithLabelValues(name, status.label()).Add(0)
+	}
+	cacheEntries.WithLabelValues(name).Set(0)
+
+	return c
+}
+
+// Fetch returns the value of key, from the cache while fresh and from fn otherwise
+// When fn fails with an error the staleIf predicate accepts, an expired value no
+// older than TTL+MaxStale is returned instead with CacheStale
+func (c *Cache[V]) Fetch(ctx context.Context, key string, fn func(ctx context.Context) (V, error)) (V, CacheStatus, error) {
+	cached, age, ok := c.lookup(key)
+	if ok && age < c.ttl {
+		c.record(CacheHit)
+		return cached, CacheHit, nil
+	}
+
+	value, err := fn(ctx)
+	if err == nil {
+		c.store(key, value)
+		c.record(CacheMiss)
+		return value, CacheMiss, nil
+	}
+
+	if ok && c.staleIf(err) {
+		c.record(CacheStale)
+		return cached, CacheStale, nil
+	}
+	c.record(CacheMiss)
+	var zero V
+	return zero, CacheMiss, err
+}
+
+// Name returns the name the cache reports metrics under
+func (c *Cache[V]) Name() string {
+	return c.name
+}
+
+// lookup returns the value of key and its age, dropping it once too old to serve
+func (c *Cache[V]) lookup(key string) (V, time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.entries[key]
+	if !ok {
+		return zero, 0, false
+	}
+	entry := elem.Value.(*cacheEntry[V])
+	age := time.Since(entry.storedAt)
+	if age >= c.ttl+c.maxStale {
+		c.remove(elem)
+		return zero, 0, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.value, age, true
+}
+
+// store caches value under key, evicting the least recently used entry if full
+func (c *Cache[V]) store(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry[V])
+		entry.value = value
+		entry.storedAt = time.Now()
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry[V]{key: key, value: value, storedAt: time.Now()})
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+	cacheEntries.WithLabelValues(c.name).Set(float64(c.lru.Len()))
+}
+
+// remove drops an entry
+// Callers hold c.mu
+func (c *Cache[V]) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry[V]).key)
+	cacheEntries.WithLabelValues(c.name).Set(float64(c.lru.Len()))
+}
+
+// record counts a lookup by its result
+func (c *Cache[V]) record(status CacheStatus) {
+	cacheRequests.WithLabelValues(c.name, status.label()).Inc()
+}
+
+// label returns the metric label of the status
+func (s CacheStatus) label() string {
+	return strings.ToLower(string(s))
+}
@@ -0,0 +1,97 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// origin counts the calls made to a fake origin
+type origin struct {
+	calls int
+	err   error
+}
+
+func (o *origin) fetch(value string) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		o.calls++
+		if o.err != nil {
+			return "", o.err
+		}
+		return value, nil
+	}
+}
+
+func TestCacheServesFreshEntries(t *testing.T) {
+	c := NewCache[string]("test-fresh", WithCacheConfig(CacheConfig{MaxEntries: 10, TTL: time.Minute}))
+	var o origin
+
+	if v, status, err := c.Fetch(context.Background(), "a", o.fetch("1")); err != nil || v != "1" || status != CacheMiss {
+		t.Fatalf("first fetch = %q, %v, %v; want 1, MISS", v, status, err)
+	}
+	if v, status, err := c.Fetch(context.Background(), "a", o.fetch("2")); err != nil || v != "1" || status != CacheHit {
+		t.Fatalf("second fetch = %q, %v, %v; want 1, HIT", v, status, err)
+	}
+	if o.calls != 1 {
+		t.Fatalf("origin calls = %d, want 1", o.calls)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewCache[string]("test-lru", WithCacheConfig(CacheConfig{MaxEntries: 2, TTL: time.Minute}))
+	var o origin
+	ctx := context.Background()
+
+	c.Fetch(ctx, "a", o.fetch("a"))
+	c.Fetch(ctx, "b", o.fetch("b"))
+	c.Fetch(ctx, "a", o.fetch("a")) // a is now more recently used than b
+	c.Fetch(ctx, "c", o.fetch("c"))
+
+	if _, status, _ := c.Fetch(ctx, "a", o.fetch("a")); status != CacheHit {
+		t.Fatalf("a = %v, want HIT", status)
+	}
+	if _, status, _ := c.Fetch(ctx, "b", o.fetch("b")); status != CacheMiss {
+		t.Fatalf("b = %v, want MISS after eviction", status)
+	}
+}
+
+func TestCacheServesStaleOnError(t *testing.T) {
+	c := NewCache[string]("test-stale", WithCacheConfig(CacheConfig{MaxEntries: 10, TTL: time.Millisecond, MaxStale: time.Minute}))
+	o := origin{}
+	ctx := context.Background()
+
+	c.Fetch(ctx, "a", o.fetch("1"))
+	time.Sleep(2 * time.Millisecond)
+
+	o.err = ErrCircuitOpen
+	if v, status, err := c.Fetch(ctx, "a", o.fetch("2")); err != nil || v != "1" || status != CacheStale {
+		t.Fatalf("fetch during outage = %q, %v, %v; want 1, STALE", v, status, err)
+	}
+
+	// Rejections are passed through rather than hidden behind a stale value
+	o.err = NewStatusError("test", 404, "")
+	if _, status, err := c.Fetch(ctx, "a", o.fetch("2")); err == nil || status != CacheMiss {
+		t.Fatalf("fetch of rejected key = %v, %v; want error, MISS", status, err)
+	}
+
+	// Keys never cached have nothing to fall back on
+	o.err = ErrCircuitOpen
+	if _, _, err := c.Fetch(ctx, "b", o.fetch("2")); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("fetch of uncached key = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestCacheDropsEntriesPastMaxStale(t *testing.T) {
+	c := NewCache[string]("test-max-stale", WithCacheConfig(CacheConfig{MaxEntries: 10, TTL: time.Millisecond, MaxStale: time.Millisecond}))
+	o := origin{}
+	ctx := context.Background()
+
+	c.Fetch(ctx, "a", o.fetch("1"))
+	time.Sleep(3 * time.Millisecond)
+
+	o.err = ErrCircuitOpen
+	if _, _, err := c.Fetch(ctx, "a", o.fetch("2")); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("fetch past max stale = %v, want ErrCircuitOpen", err)
+	}
+}
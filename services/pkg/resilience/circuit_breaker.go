@@ -0,0 +1,497 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// CircuitState represents the state of the circuit breaker
+type CircuitState int
+
+const (
+	StateClosed CircuitState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+// circuitStates lists every state, in the order they are exported as metrics
+var circuitStates = []CircuitState{StateClosed, StateOpen, StateHalfOpen}
+
+// Call results reported by circuit_breaker_calls_total
+const (
+	callResultSuccess  = "success"
+	callResultFailure  = "failure"
+	callResultRejected = "rejected"
+	callResultSlow     = "slow"
+	callResultIgnored  = "ignored"
+)
+
+var (
+	circuitBreakerState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "Circuit breaker state, 1 for the current state and 0 for the others",
+		},
+		[]string{"service", "state"},
+	)
+
+	circuitBreakerTransitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_transitions_total",
+			Help: "Total number of circuit breaker state transitions",
+		},
+		[]string{"service", "from", "to"},
+	)
+
+	circuitBreakerCalls = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_calls_total",
+			Help: "Total number of calls through the circuit breaker by result (success, failure, rejected, slow, ignored)",
+		},
+		[]string{"service", "result"},
+	)
+
+	circuitBreakerFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_failures_total",
+			Help: "Total number of calls the circuit breaker recorded as failures",
+		},
+		[]string{"service"},
+	)
+
+	circuitBreakerSlowCalls = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_slow_calls_total",
+			Help: "Total number of calls slower than the circuit breaker slow call threshold",
+		},
+		[]string{"service"},
+	)
+)
+
+var (
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrTooManyProbes is returned in half-open state when all trial requests are in use
+	// It wraps ErrCircuitOpen so callers can treat both alike
+	ErrTooManyProbes = fmt.Errorf("%w: too many half-open probes", ErrCircuitOpen)
+)
+
+// CircuitBreaker implements the circuit breaker pattern with generics and sliding window
+type CircuitBreaker[T any] struct {
+	state                CircuitState
+	window               *slidingWindow
+	failureThreshold     int
+	failureRateThreshold float64
+	slowCallDuration     time.Duration
+	slowCallRate         float64
+	successThreshold     int
+	minimumRequests      int
+	halfOpenMaxProbes    int
+	halfOpenProbes       int
+	halfOpenSuccesses    int
+	generation           uint64
+	timeout              time.Duration
+	openedAt             time.Time
+	forced               bool
+	isFailure            func(error) bool
+	history              *eventHistory
+	pending              []StateChangeEvent
+	hooks                []func(from, to CircuitState)
+	mu                   sync.RWMutex
+	serviceName          string
+}
+
+// NewCircuitBreaker creates a new circuit breaker with sliding window
+// Settings default to DefaultCircuitBreakerConfig and can be overridden with options
+// The circuit breaker registers itself with DefaultRegistry
+func NewCircuitBreaker[T any](serviceName string, opts ...CircuitBreakerOption) *CircuitBreaker[T] {
+	o := circuitBreakerOptions{
+		config:           DefaultCircuitBreakerConfig(),
+		isFailure:        DefaultIsFailure,
+		eventHistorySize: defaultEventHistorySize,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	cfg := o.config
+
+	cb := &CircuitBreaker[T]{
+		state:                StateClosed,
+		window:               newSlidingWindow(cfg.FailureWindow, defaultWindowBuckets),
+		failureThreshold:     cfg.FailureThreshold,
+		failureRateThreshold: cfg.FailureRateThreshold,
+		slowCallDuration:     cfg.SlowCallDuration,
+		slowCallRate:         cfg.SlowCallRateThreshold,
+		successThreshold:     cfg.SuccessThreshold,
+		minimumRequests:      cfg.MinimumRequests,
+		halfOpenMaxProbes:    cfg.HalfOpenMaxProbes,
+		timeout:              cfg.OpenTimeout,
+		isFailure:            o.isFailure,
+		history:              newEventHistory(o.eventHistorySize),
+		serviceName:          serviceName,
+	}
+
+	// Initialize metrics to CLOSED state so they show up in Grafana
+	setStateGauge(serviceName, StateClosed)
+	circuitBreakerFailures.WithLabelValues(serviceName).Add(0)
+	circuitBreakerSlowCalls.WithLabelValues(serviceName).Add(0)
+	for _, result := range []string{callResultSuccess, callResultFailure, callResultRejected, callResultSlow, callResultIgnored} {
+		circuitBreakerCalls.WithLabelValues(serviceName, result).Add(0)
+	}
+
+	DefaultRegistry.RegisterCircuitBreaker(cb)
+
+	return cb
+}
+
+// Execute runs the provided function with circuit breaker protection
+func (cb *CircuitBreaker[T]) Execute(fn func() (T, error)) (T, error) {
+	var zero T
+
+	// Check if circuit is open
+	generation, err := cb.canAttempt()
+	if err != nil {
+		circuitBreakerCalls.WithLabelValues(cb.serviceName, callResultRejected).Inc()
+		return zero, err
+	}
+
+	// Execute the function, timing it for slow call detection
+	start := time.Now()
+	result, err := fn()
+	slow := cb.isSlow(time.Since(start))
+
+	// Record result
+	if err != nil {
+		if !cb.isFailure(err) {
+			// Errors that say nothing about the service's health aren't recorded
+			circuitBreakerCalls.WithLabelValues(cb.serviceName, callResultIgnored).Inc()
+			cb.recordIgnored(generation)
+			return zero, err
+		}
+		circuitBreakerCalls.WithLabelValues(cb.serviceName, callResultFailure).Inc()
+		cb.recordFailure(generation, slow)
+		return zero, err
+	}
+
+	if slow {
+		circuitBreakerCalls.WithLabelValues(cb.serviceName, callResultSlow).Inc()
+	} else {
+		circuitBreakerCalls.WithLabelValues(cb.serviceName, callResultSuccess).Inc()
+	}
+	cb.recordSuccess(generation, slow)
+	return result, nil
+}
+
+// Do runs fn with circuit breaker protection, discarding the result type
+// This allows the circuit breaker to be used as a Policy
+func (cb *CircuitBreaker[T]) Do(ctx context.Context, fn func() error) error {
+	_, err := cb.Execute(func() (T, error) {
+		var zero T
+		return zero, fn()
+	})
+	return err
+}
+
+// Name returns the service name the circuit breaker reports metrics under
+func (cb *CircuitBreaker[T]) Name() string {
+	return cb.serviceName
+}
+
+// canAttempt checks if a request can be attempted
+// It returns the state generation the request was admitted in, so its result
+// can be matched to the half-open probe it occupies
+func (cb *CircuitBreaker[T]) canAttempt() (uint64, error) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	switch cb.state {
+	case StateClosed:
+		return cb.generation, nil
+
+	case StateOpen:
+		// Check if timeout has elapsed, unless an operator is holding the circuit open
+		if !cb.forced && time.Since(cb.openedAt) > cb.timeout {
+			cb.setState(StateHalfOpen, "open timeout elapsed")
+			cb.halfOpenProbes++
+			return cb.generation, nil
+		}
+		return cb.generation, ErrCircuitOpen
+
+	case StateHalfOpen:
+		// Only a limited number of trial requests may reach the recovering service
+		if cb.halfOpenProbes >= cb.halfOpenMaxProbes {
+			return cb.generation, ErrTooManyProbes
+		}
+		cb.halfOpenProbes++
+		return cb.generation, nil
+
+	default:
+		return cb.generation, ErrCircuitOpen
+	}
+}
+
+// isSlow reports whether a call that took elapsed counts as a slow call
+func (cb *CircuitBreaker[T]) isSlow(elapsed time.Duration) bool {
+	if cb.slowCallDuration <= 0 || elapsed < cb.slowCallDuration {
+		return false
+	}
+	circuitBreakerSlowCalls.WithLabelValues(cb.serviceName).Inc()
+	return true
+}
+
+// recordFailure records a failed attempt in the sliding window
+func (cb *CircuitBreaker[T]) recordFailure(generation uint64, slow bool) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	now := time.Now()
+	cb.window.record(now, true, slow)
+
+	circuitBreakerFailures.WithLabelValues(cb.serviceName).Inc()
+
+	// A forced state only changes through the admin API
+	if cb.forced {
+		return
+	}
+
+	switch cb.state {
+	case StateClosed:
+		if reason := cb.tripReason(now); reason != "" {
+			cb.setState(StateOpen, reason)
+		}
+
+	case StateHalfOpen:
+		// Any failed probe in half-open state reopens the circuit
+		// Calls admitted before the circuit opened don't decide recovery
+		if generation == cb.generation {
+			cb.setState(StateOpen, "half-open probe failed")
+		}
+	}
+}
+
+// recordSuccess records a successful attempt
+// A slow success still drains capacity, so it counts toward the slow call rate
+// and fails a half-open probe
+func (cb *CircuitBreaker[T]) recordSuccess(generation uint64, slow bool) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	now := time.Now()
+	cb.window.record(now, false, slow)
+
+	// A forced state only changes through the admin API
+	if cb.forced {
+		return
+	}
+
+	switch cb.state {
+	case StateHalfOpen:
+		if generation != cb.generation {
+			return
+		}
+
+		// A slow probe means the service hasn't recovered yet
+		if slow {
+			cb.setState(StateOpen, "half-open probe was slow")
+			return
+		}
+
+		// Enough successful probes in half-open move to closed
+		cb.halfOpenProbes--
+		cb.halfOpenSuccesses++
+		if cb.halfOpenSuccesses >= cb.successThreshold {
+			cb.window.reset(now) // Reset failure history
+			cb.setState(StateClosed, fmt.Sprintf("%d half-open probes succeeded", cb.halfOpenSuccesses))
+		}
+
+	case StateClosed:
+		// Don't reset failures as we use sliding window
+		// A slow success can still push the slow call rate over its threshold
+		if !slow {
+			return
+		}
+		if reason := cb.tripReason(now); reason != "" {
+			cb.setState(StateOpen, reason)
+		}
+	}
+}
+
+// recordIgnored releases the half-open probe held by a call whose error was ignored
+func (cb *CircuitBreaker[T]) recordIgnored(generation uint64) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if cb.state == StateHalfOpen && generation == cb.generation {
+		cb.halfOpenProbes--
+	}
+}
+
+// tripReason reports why the calls in the current window should open the circuit,
+// or an empty string if they shouldn't
+// The circuit trips when the slow call rate reaches its threshold, or on failures:
+// the percentage of failed calls with a failure rate threshold, the absolute count otherwise
+func (cb *CircuitBreaker[T]) tripReason(now time.Time) string {
+	counts := cb.window.totals(now)
+
+	// Don't open until enough calls have been observed in the window
+	if counts.requests == 0 || counts.requests < cb.minimumRequests {
+		return ""
+	}
+
+	if cb.slowCallRate > 0 {
+		slowRate := float64(counts.slow) * 100 / float64(counts.requests)
+		if slowRate >= cb.slowCallRate {
+			return fmt.Sprintf("slow call rate %.1f%% of %d calls reached threshold %.1f%%", slowRate, counts.requests, cb.slowCallRate)
+		}
+	}
+
+	if cb.failureRateThreshold > 0 {
+		failureRate := float64(counts.failures) * 100 / float64(counts.requests)
+		if failureRate >= cb.failureRateThreshold {
+			return fmt.Sprintf("failure rate %.1f%% of %d calls reached threshold %.1f%%", failureRate, counts.requests, cb.failureRateThreshold)
+		}
+		return ""
+	}
+	if counts.failures >= cb.failureThreshold {
+		return fmt.Sprintf("%d failures in window reached threshold %d", counts.failures, cb.failureThreshold)
+	}
+	return ""
+}
+
+// setState updates the circuit breaker state and metrics
+// The transition is logged and recorded; hooks run once the lock is released
+// Staying in the same state, as when an operator resets a closed circuit, starts a new
+// generation of calls but is not a transition
+func (cb *CircuitBreaker[T]) setState(state CircuitState, reason string) {
+	event := StateChangeEvent{
+		Service: cb.serviceName,
+		From:    cb.state,
+		To:      state,
+		Reason:  reason,
+		Time:    time.Now(),
+	}
+
+	cb.state = state
+	cb.generation++
+	cb.halfOpenProbes = 0
+	cb.halfOpenSuccesses = 0
+	if state == StateOpen {
+		cb.openedAt = event.Time
+	}
+	setStateGauge(cb.serviceName, state)
+	if event.From == event.To {
+		return
+	}
+	circuitBreakerTransitions.WithLabelValues(cb.serviceName, event.From.String(), event.To.String()).Inc()
+
+	cb.history.add(event)
+	cb.pending = append(cb.pending, event)
+	slog.Info("circuit breaker state changed",
+		"service", cb.serviceName,
+		"from", event.From.String(),
+		"to", event.To.String(),
+		"reason", reason,
+	)
+}
+
+// setStateGauge exports state as the current state of the named circuit breaker
+// Each state is its own series so alerts can match on circuit_breaker_state{state="open"}
+func setStateGauge(serviceName string, state CircuitState) {
+	for _, s := range circuitStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		circuitBreakerState.WithLabelValues(serviceName, s.String()).Set(value)
+	}
+}
+
+// unlock releases the lock and then runs the hooks for any transitions made while holding it
+// Running hooks outside the lock lets them safely call back into the circuit breaker
+func (cb *CircuitBreaker[T]) unlock() {
+	events := cb.pending
+	cb.pending = nil
+	hooks := cb.hooks
+	cb.mu.Unlock()
+
+	for _, event := range events {
+		for _, hook := range hooks {
+			hook(event.From, event.To)
+		}
+	}
+}
+
+// OnStateChange registers a callback run after every state transition
+func (cb *CircuitBreaker[T]) OnStateChange(hook func(from, to CircuitState)) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.hooks = append(cb.hooks, hook)
+}
+
+// Events returns the most recent state transitions, oldest first
+func (cb *CircuitBreaker[T]) Events() []StateChangeEvent {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.history.list()
+}
+
+// ForceOpen opens the circuit and holds it open until ForceClose or Reset
+func (cb *CircuitBreaker[T]) ForceOpen() {
+	cb.mu.Lock()
+	defer cb.unlock()
+	cb.forced = true
+	cb.setState(StateOpen, "forced open by operator")
+}
+
+// ForceClose closes the circuit and holds it closed until ForceOpen or Reset
+// Failures are still recorded but don't open the circuit
+func (cb *CircuitBreaker[T]) ForceClose() {
+	cb.mu.Lock()
+	defer cb.unlock()
+	cb.forced = true
+	cb.setState(StateClosed, "forced closed by operator")
+}
+
+// Reset clears any forced state and the failure history, returning the circuit to closed
+func (cb *CircuitBreaker[T]) Reset() {
+	cb.mu.Lock()
+	defer cb.unlock()
+	cb.forced = false
+	cb.window.reset(time.Now())
+	cb.setState(StateClosed, "reset by operator")
+}
+
+// Snapshot returns the current state and window counts of the circuit breaker
+func (cb *CircuitBreaker[T]) Snapshot() CircuitBreakerSnapshot {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	counts := cb.window.totals(now)
+	snapshot := CircuitBreakerSnapshot{
+		Name:              cb.serviceName,
+		State:             cb.state,
+		Forced:            cb.forced,
+		RequestsInWindow:  counts.requests,
+		FailuresInWindow:  counts.failures,
+		SlowCallsInWindow: counts.slow,
+		HalfOpenProbes:    cb.halfOpenProbes,
+	}
+	if cb.state == StateOpen && !cb.forced {
+		snapshot.TimeUntilHalfOpen = max(cb.openedAt.Add(cb.timeout).Sub(now), 0)
+	}
+	return snapshot
+}
+
+// GetState returns the current circuit breaker state
+func (cb *CircuitBreaker[T]) GetState() CircuitState {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.state
+}
@@ -0,0 +1,361 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errUpstream = errors.New("upstream failed")
+
+func failN[T any](cb *CircuitBreaker[T], n int) {
+	for i := 0; i < n; i++ {
+		_, _ = cb.Execute(func() (T, error) {
+			var zero T
+			return zero, errUpstream
+		})
+	}
+}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-open", WithFailureThreshold(3), WithOpenTimeout(time.Minute))
+
+	failN(cb, 2)
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state after 2 failures = %v, want closed", got)
+	}
+
+	failN(cb, 1)
+	if got := cb.GetState(); got != StateOpen {
+		t.Fatalf("state after 3 failures = %v, want open", got)
+	}
+
+	called := false
+	_, err := cb.Execute(func() (int, error) {
+		called = true
+		return 1, nil
+	})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if called {
+		t.Fatal("function was called while circuit was open")
+	}
+}
+
+func TestCircuitBreakerHalfOpenRecovers(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-recover", WithFailureThreshold(1), WithOpenTimeout(10*time.Millisecond))
+
+	failN(cb, 1)
+	if got := cb.GetState(); got != StateOpen {
+		t.Fatalf("state = %v, want open", got)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	got, err := cb.Execute(func() (int, error) { return 42, nil })
+	if err != nil || got != 42 {
+		t.Fatalf("Execute = (%d, %v), want (42, nil)", got, err)
+	}
+	if state := cb.GetState(); state != StateClosed {
+		t.Fatalf("state = %v, want closed", state)
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-reopen", WithFailureThreshold(1), WithOpenTimeout(10*time.Millisecond))
+
+	failN(cb, 1)
+	time.Sleep(20 * time.Millisecond)
+	failN(cb, 1)
+
+	if got := cb.GetState(); got != StateOpen {
+		t.Fatalf("state = %v, want open", got)
+	}
+}
+
+func TestCircuitBreakerAsPolicy(t *testing.T) {
+	var p Policy = NewCircuitBreaker[string]("test-policy", WithFailureThreshold(1))
+
+	if p.Name() != "test-policy" {
+		t.Fatalf("Name() = %q, want %q", p.Name(), "test-policy")
+	}
+	if err := p.Do(t.Context(), func() error { return errUpstream }); !errors.Is(err, errUpstream) {
+		t.Fatalf("err = %v, want errUpstream", err)
+	}
+	if err := p.Do(t.Context(), func() error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestCircuitBreakerSuccessThreshold(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-success-threshold",
+		WithFailureThreshold(1),
+		WithOpenTimeout(10*time.Millisecond),
+		WithSuccessThreshold(2),
+	)
+
+	failN(cb, 1)
+	time.Sleep(20 * time.Millisecond)
+
+	_, _ = cb.Execute(func() (int, error) { return 1, nil })
+	if got := cb.GetState(); got != StateHalfOpen {
+		t.Fatalf("state after 1 success = %v, want half-open", got)
+	}
+
+	_, _ = cb.Execute(func() (int, error) { return 1, nil })
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state after 2 successes = %v, want closed", got)
+	}
+}
+
+func TestCircuitBreakerMinimumRequests(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-minimum-requests",
+		WithFailureThreshold(2),
+		WithMinimumRequests(4),
+	)
+
+	failN(cb, 3)
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state after 3 calls = %v, want closed", got)
+	}
+
+	failN(cb, 1)
+	if got := cb.GetState(); got != StateOpen {
+		t.Fatalf("state after 4 calls = %v, want open", got)
+	}
+}
+
+func TestCircuitBreakerFailureWindow(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-failure-window",
+		WithFailureThreshold(2),
+		WithFailureWindow(10*time.Millisecond),
+	)
+
+	failN(cb, 1)
+	time.Sleep(20 * time.Millisecond)
+	failN(cb, 1)
+
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state = %v, want closed once the first failure left the window", got)
+	}
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-failure-rate",
+		WithFailureRateThreshold(50),
+		WithMinimumRequests(10),
+	)
+
+	succeed := func(n int) {
+		for i := 0; i < n; i++ {
+			_, _ = cb.Execute(func() (int, error) { return 1, nil })
+		}
+	}
+
+	// 4 failures out of 9 calls: below the minimum volume
+	succeed(5)
+	failN(cb, 4)
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state below minimum requests = %v, want closed", got)
+	}
+
+	// 5 failures out of 10 calls: 50% failure rate
+	failN(cb, 1)
+	if got := cb.GetState(); got != StateOpen {
+		t.Fatalf("state at 50%% failure rate = %v, want open", got)
+	}
+}
+
+func TestCircuitBreakerFailureRateBelowThreshold(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-failure-rate-below",
+		WithFailureRateThreshold(50),
+		WithMinimumRequests(4),
+	)
+
+	for i := 0; i < 20; i++ {
+		_, _ = cb.Execute(func() (int, error) { return 1, nil })
+	}
+	failN(cb, 10)
+
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state at 33%% failure rate = %v, want closed", got)
+	}
+}
+
+func TestCircuitBreakerLimitsHalfOpenProbes(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-probes",
+		WithFailureThreshold(1),
+		WithOpenTimeout(10*time.Millisecond),
+		WithHalfOpenMaxProbes(2),
+		WithSuccessThreshold(2),
+	)
+
+	failN(cb, 1)
+	time.Sleep(20 * time.Millisecond)
+
+	// Occupy both probes with calls that block until released
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := cb.Execute(func() (int, error) {
+				started <- struct{}{}
+				<-release
+				return 1, nil
+			})
+			done <- err
+		}()
+	}
+	<-started
+	<-started
+
+	_, err := cb.Execute(func() (int, error) { return 1, nil })
+	if !errors.Is(err, ErrTooManyProbes) {
+		t.Fatalf("third probe err = %v, want ErrTooManyProbes", err)
+	}
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("ErrTooManyProbes should match ErrCircuitOpen")
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("probe err = %v", err)
+		}
+	}
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state after 2 successful probes = %v, want closed", got)
+	}
+}
+
+func TestCircuitBreakerIgnoresStaleCallsInHalfOpen(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-stale",
+		WithFailureThreshold(1),
+		WithOpenTimeout(10*time.Millisecond),
+	)
+
+	// A slow call admitted while closed finishes after the circuit moved to half-open
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		_, _ = cb.Execute(func() (int, error) {
+			close(started)
+			<-release
+			return 0, errUpstream
+		})
+		close(done)
+	}()
+	<-started
+
+	failN(cb, 1)
+	time.Sleep(20 * time.Millisecond)
+
+	probeRelease := make(chan struct{})
+	probeStarted := make(chan struct{})
+	probeDone := make(chan error)
+	go func() {
+		_, err := cb.Execute(func() (int, error) {
+			close(probeStarted)
+			<-probeRelease
+			return 1, nil
+		})
+		probeDone <- err
+	}()
+	<-probeStarted
+
+	close(release)
+	<-done
+	if got := cb.GetState(); got != StateHalfOpen {
+		t.Fatalf("state after stale failure = %v, want half-open", got)
+	}
+
+	close(probeRelease)
+	if err := <-probeDone; err != nil {
+		t.Fatalf("probe err = %v", err)
+	}
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state after successful probe = %v, want closed", got)
+	}
+}
+
+func TestCircuitBreakerSlowCallRate(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-slow-rate",
+		WithSlowCallThreshold(5*time.Millisecond, 50),
+		WithMinimumRequests(4),
+	)
+
+	slowCall := func() (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		_, _ = cb.Execute(func() (int, error) { return 1, nil })
+	}
+	_, _ = cb.Execute(slowCall)
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state below minimum requests = %v, want closed", got)
+	}
+
+	_, err := cb.Execute(slowCall)
+	if err != nil {
+		t.Fatalf("slow call err = %v, want nil", err)
+	}
+	if got := cb.GetState(); got != StateOpen {
+		t.Fatalf("state at 50%% slow calls = %v, want open", got)
+	}
+}
+
+func TestCircuitBreakerSlowProbeReopens(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-slow-probe",
+		WithFailureThreshold(1),
+		WithOpenTimeout(10*time.Millisecond),
+		WithSlowCallThreshold(5*time.Millisecond, 0),
+	)
+
+	failN(cb, 1)
+	time.Sleep(20 * time.Millisecond)
+
+	_, _ = cb.Execute(func() (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	})
+	if got := cb.GetState(); got != StateOpen {
+		t.Fatalf("state after slow probe = %v, want open", got)
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-ignore", WithFailureThreshold(1))
+
+	badRequest := NewStatusError("test", 400, "invalid amount")
+	_, err := cb.Execute(func() (int, error) { return 0, badRequest })
+	if !errors.Is(err, badRequest) {
+		t.Fatalf("err = %v, want the upstream error", err)
+	}
+	_, _ = cb.Execute(func() (int, error) { return 0, context.Canceled })
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state after ignored errors = %v, want closed", got)
+	}
+
+	_, _ = cb.Execute(func() (int, error) { return 0, NewStatusError("test", 503, "") })
+	if got := cb.GetState(); got != StateOpen {
+		t.Fatalf("state after 503 = %v, want open", got)
+	}
+}
+
+func TestCircuitBreakerCustomIsFailure(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-custom-is-failure",
+		WithFailureThreshold(1),
+		WithIsFailure(func(err error) bool { return !errors.Is(err, errUpstream) }),
+	)
+
+	failN(cb, 3)
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state = %v, want closed", got)
+	}
+}
@@ -0,0 +1,245 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var coalescedRequests = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "coalesced_requests_total",
+		Help: "Total number of requests served by joining an identical call already in flight",
+	},
+	[]string{"service"},
+)
+
+// coalescedCall is a call shared by every caller of the same key
+type coalescedCall[T any] struct {
+	done    chan struct{} // closed once value and err are set
+	value   T
+	err     error
+	waiters int
+	ctx     *callContext
+}
+
+// Coalescer collapses concurrent calls for the same key into one (singleflight)
+// The shared call runs detached from any single caller: a caller giving up only
+// stops waiting, and the call is cancelled once every caller has given up
+// Its deadline is the latest among the callers still waiting, so it is propagated
+// downstream and ends the call once no caller can use the result
+type Coalescer[T any] struct {
+	mu          sync.Mutex
+	calls       map[string]*coalescedCall[T]
+	serviceName string
+}
+
+// NewCoalescer creates a coalescer
+func NewCoalescer[T any](serviceName string) *Coalescer[T] {
+	// Initialize metrics to 0 so they show up in Grafana immediately
+	coalescedRequests.WithLabelValues(serviceName).Add(0)
+
+	return &Coalescer[T]{
+		calls:       make(map[string]*coalescedCall[T]),
+		serviceName: serviceName,
+	}
+}
+
+// Do runs fn for key, or joins the call for key already in flight and shares its result
+// fn gets a context carrying the values of the first caller but none of its cancellation,
+// and the latest deadline of the callers waiting for it
+// Returns the context error if ctx is done before the call completes
+func (c *Coalescer[T]) Do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	c.mu.Lock()
+	call, ok := c.calls[key]
+	if ok {
+		call.waiters++
+		call.ctx.join(ctx)
+		c.mu.Unlock()
+		coalescedRequests.WithLabelValues(c.serviceName).Inc()
+	} else {
+		callCtx := newCallContext(ctx)
+		callCtx.join(ctx)
+		call = &coalescedCall[T]{done: make(chan struct{}), waiters: 1, ctx: callCtx}
+		c.calls[key] = call
+		c.mu.Unlock()
+		go c.run(key, call, fn)
+	}
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		c.leave(ctx, key, call)
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// run makes the shared call and hands its result to every waiter
+func (c *Coalescer[T]) run(key string, call *coalescedCall[T], fn func(ctx context.Context) (T, error)) {
+	defer call.ctx.cancel(context.Canceled)
+
+	value, err := fn(call.ctx)
+
+	c.mu.Lock()
+	c.forget(key, call)
+	c.mu.Unlock()
+
+	call.value, call.err = value, err
+	close(call.done)
+}
+
+// leave stops the caller of ctx waiting, cancelling the call if nobody else waits for it
+func (c *Coalescer[T]) leave(ctx context.Context, key string, call *coalescedCall[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	call.waiters--
+	if call.waiters == 0 {
+		// Later callers start afresh rather than join a cancelled call
+		c.forget(key, call)
+		call.ctx.cancel(context.Canceled)
+		return
+	}
+	call.ctx.leave(ctx)
+}
+
+// forget removes call from the calls in flight unless a newer call replaced it
+// Callers hold c.mu
+func (c *Coalescer[T]) forget(key string, call *coalescedCall[T]) {
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+}
+
+// callContext is the context of a shared call
+// It carries the values of the first caller, and the latest deadline among the
+// callers waiting; a caller without a deadline leaves the call without one
+type callContext struct {
+	context.Context // the first caller's context without its cancellation, for values
+
+	mu        sync.Mutex
+	done      chan struct{}
+	err       error
+	deadlines []time.Time // of the waiting callers with a deadline
+	unbounded int         // waiting callers without a deadline
+	timer     *time.Timer
+}
+
+// newCallContext creates the context of a call first made with ctx
+func newCallContext(ctx context.Context) *callContext {
+	return &callContext{
+		Context: context.WithoutCancel(ctx),
+		done:    make(chan struct{}),
+	}
+}
+
+// Deadline returns the latest deadline of the waiting callers
+func (c *callContext) Deadline() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.latest()
+}
+
+// Done is closed when the call is cancelled or its deadline passes
+func (c *callContext) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns context.Canceled or context.DeadlineExceeded once Done is closed
+func (c *callContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// join adds the deadline of a caller that waits for the call
+func (c *callContext) join(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		c.deadlines = append(c.deadlines, deadline)
+	} else {
+		c.unbounded++
+	}
+	c.schedule()
+}
+
+// leave removes the deadline of a caller that stopped waiting
+func (c *callContext) leave(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		for i, d := range c.deadlines {
+			if d.Equal(deadline) {
+				c.deadlines = append(c.deadlines[:i], c.deadlines[i+1:]...)
+				break
+			}
+		}
+	} else {
+		c.unbounded--
+	}
+	c.schedule()
+}
+
+// cancel closes Done with err, unless it is already closed
+func (c *callContext) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
+
+// latest returns the deadline of the call, if any
+// Callers hold c.mu
+func (c *callContext) latest() (time.Time, bool) {
+	if c.unbounded > 0 || len(c.deadlines) == 0 {
+		return time.Time{}, false
+	}
+	latest := c.deadlines[0]
+	for _, d := range c.deadlines[1:] {
+		if d.After(latest) {
+			latest = d
+		}
+	}
+	return latest, true
+}
+
+// schedule arms the timer ending the call at its deadline
+// Callers hold c.mu
+func (c *callContext) schedule() {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	deadline, ok := c.latest()
+	if !ok || c.err != nil {
+		return
+	}
+	c.timer = time.AfterFunc(time.Until(deadline), c.expire)
+}
+
+// expire ends the call if its deadline passed
+// A timer stopped too late to keep it from firing finds the deadline moved on
+func (c *callContext) expire() {
+	c.mu.Lock()
+	deadline, ok := c.latest()
+	c.mu.Unlock()
+
+	if ok && !time.Now().Before(deadline) {
+		c.cancel(context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,249 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// waitCoalesced blocks until n callers wait for key
+func waitCoalesced[T any](t *testing.T, c *Coalescer[T], key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.mu.Lock()
+		call, ok := c.calls[key]
+		waiters := 0
+		if ok {
+			waiters = call.waiters
+		}
+		c.mu.Unlock()
+		if waiters == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("waiters = %d, want %d", waiters, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoalescerSharesOneCall(t *testing.T) {
+	c := NewCoalescer[string]("test-share")
+	coalesced := testutil.ToFloat64(coalescedRequests.WithLabelValues("test-share"))
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make(chan string, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.Do(context.Background(), "order-1", func(context.Context) (string, error) {
+				calls.Add(1)
+				<-release
+				return "ok", nil
+			})
+			if err != nil {
+				t.Errorf("Do: %v", err)
+			}
+			results <- v
+		}()
+	}
+	waitCoalesced(t, c, "order-1", 5)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for v := range results {
+		if v != "ok" {
+			t.Fatalf("result = %q, want ok", v)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("calls = %d, want 1", got)
+	}
+	if got := testutil.ToFloat64(coalescedRequests.WithLabelValues("test-share")) - coalesced; got != 4 {
+		t.Fatalf("coalesced = %v, want 4", got)
+	}
+}
+
+func TestCoalescerCallerCancellationSparesOthers(t *testing.T) {
+	c := NewCoalescer[string]("test-cancel-one")
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (string, error) {
+		select {
+		case <-release:
+			return "ok", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.Do(ctx, "order-1", fn)
+		first <- err
+	}()
+	waitCoalesced(t, c, "order-1", 1)
+
+	second := make(chan string, 1)
+	go func() {
+		v, _ := c.Do(context.Background(), "order-1", fn)
+		second <- v
+	}()
+	waitCoalesced(t, c, "order-1", 2)
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller = %v, want context.Canceled", err)
+	}
+	close(release)
+	if v := <-second; v != "ok" {
+		t.Fatalf("remaining caller = %q, want ok", v)
+	}
+}
+
+func TestCoalescerCancelsCallWhenEveryCallerLeaves(t *testing.T) {
+	c := NewCoalescer[string]("test-cancel-all")
+	cancelled := make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Do(ctx, "order-1", func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return "", ctx.Err()
+		})
+	}()
+	waitCoalesced(t, c, "order-1", 1)
+
+	cancel()
+	<-done
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("shared call was not cancelled")
+	}
+}
+
+func TestCoalescerPassesLatestDeadline(t *testing.T) {
+	c := NewCoalescer[string]("test-deadline")
+	release := make(chan struct{})
+	observed := make(chan time.Time, 1)
+	fn := func(ctx context.Context) (string, error) {
+		<-release
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			deadline = time.Time{}
+		}
+		observed <- deadline
+		return "ok", nil
+	}
+
+	early, cancelEarly := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelEarly()
+	late, cancelLate := context.WithTimeout(context.Background(), time.Hour)
+	defer cancelLate()
+	lateDeadline, _ := late.Deadline()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Do(early, "order-1", fn)
+	}()
+	waitCoalesced(t, c, "order-1", 1)
+	go c.Do(late, "order-1", fn)
+	waitCoalesced(t, c, "order-1", 2)
+
+	close(release)
+	<-done
+	if got := <-observed; !got.Equal(lateDeadline) {
+		t.Fatalf("call deadline = %v, want the latest waiter's %v", got, lateDeadline)
+	}
+}
+
+func TestCoalescerDeadline(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeouts  []time.Duration // of the callers, 0 for none
+		wantBound bool
+	}{
+		{"single caller", []time.Duration{time.Minute}, true},
+		{"caller without deadline", []time.Duration{time.Minute, 0}, false},
+		{"no deadlines", []time.Duration{0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCtx := newCallContext(context.Background())
+			for _, timeout := range tt.timeouts {
+				ctx := context.Background()
+				if timeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, timeout)
+					defer cancel()
+				}
+				callCtx.join(ctx)
+			}
+			defer callCtx.cancel(context.Canceled)
+
+			if _, ok := callCtx.Deadline(); ok != tt.wantBound {
+				t.Fatalf("has deadline = %v, want %v", ok, tt.wantBound)
+			}
+		})
+	}
+}
+
+func TestCoalescerCallExpiresAtLatestDeadline(t *testing.T) {
+	callCtx := newCallContext(context.Background())
+	early, cancelEarly := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelEarly()
+	late, cancelLate := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancelLate()
+	callCtx.join(early)
+	callCtx.join(late)
+
+	// The early caller leaving doesn't shorten the call
+	time.Sleep(20 * time.Millisecond)
+	callCtx.leave(early)
+	if err := callCtx.Err(); err != nil {
+		t.Fatalf("err before the latest deadline = %v, want nil", err)
+	}
+
+	select {
+	case <-callCtx.Done():
+		if err := callCtx.Err(); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("shared call outlived its deadline")
+	}
+}
+
+func TestCoalescerPropagatesDeadlineHeader(t *testing.T) {
+	c := NewCoalescer[string]("test-deadline-header")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	header, _ := c.Do(ctx, "order-1", func(ctx context.Context) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://order-service/api/orders/1", nil)
+		if err != nil {
+			return "", err
+		}
+		SetDeadlineHeader(req)
+		return req.Header.Get(DeadlineHeader), nil
+	})
+	if header == "" {
+		t.Fatalf("%s not set on the coalesced call", DeadlineHeader)
+	}
+}
@@ -0,0 +1,815 @@
+package resilience
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConfigFileEnv names the environment variable pointing at an optional JSON config file
+const ConfigFileEnv = "RESILIENCE_CONFIG_FILE"
+
+// fileConfig is the layout of the JSON config file
+//
+//	{
+//	  "circuit_breakers": {
+//	    "payment": {"failure_threshold": 5, "failure_window": "10s", "open_timeout": "30s"}
+//	  },
+//	  "bulkheads": {
+//	    "payment": {"max_concurrent": 10, "max_queue": 20, "max_wait": "500ms"}
+//	  },
+//	  "adaptive_limiters": {
+//	    "payment": {"enabled": true, "algorithm": "vegas", "min_limit": 2, "max_limit": 50}
+//	  },
+//	  "retries": {
+//	    "payment": {"max_attempts": 3, "base_backoff": "100ms", "max_backoff": "1s", "budget_ratio": 0.1}
+//	  },
+//	  "idempotency": {
+//	    "payments": {"ttl": "24h"}
+//	  },
+//	  "hedges": {
+//	    "order": {"enabled": true, "delay": "50ms", "percentile": 95, "max_in_flight": 10}
+//	  },
+//	  "deadlines": {
+//	    "api-gateway": {"timeout": "5s", "floor": "50ms"}
+//	  },
+//	  "fallbacks": {
+//	    "payment": {"on_circuit_open": true, "on_bulkhead_full": true, "on_timeout": false, "retry_interval": "5s"}
+//	  },
+//	  "caches": {
+//	    "order": {"max_entries": 1000, "ttl": "5s", "max_stale": "10m"}
+//	  },
+//	  "load_shedders": {
+//	    "order-service": {"max_in_flight": 100, "max_queue": 50, "target": "5ms", "interval": "100ms"}
+//	  },
+//	  "rate_limits": {
+//	    "POST /api/orders": {"rate": 10, "burst": 20, "key": "customer_id"}
+//	  }
+//	}
+type fileConfig struct {
+	CircuitBreakers  map[string]circuitBreakerFileConfig  `json:"circuit_breakers"`
+	Bulkheads        map[string]bulkheadFileConfig        `json:"bulkheads"`
+	AdaptiveLimiters map[string]adaptiveLimiterFileConfig `json:"adaptive_limiters"`
+	Retries          map[string]retryFileConfig           `json:"retries"`
+	Idempotency      map[string]idempotencyFileConfig     `json:"idempotency"`
+	Hedges           map[string]hedgeFileConfig           `json:"hedges"`
+	Deadlines        map[string]deadlineFileConfig        `json:"deadlines"`
+	Fallbacks        map[string]fallbackFileConfig        `json:"fallbacks"`
+	Caches           map[string]cacheFileConfig           `json:"caches"`
+	LoadShedders     map[string]loadShedderFileConfig     `json:"load_shedders"`
+	RateLimits       map[string]rateLimitFileConfig       `json:"rate_limits"`
+}
+
+// circuitBreakerFileConfig holds the circuit breaker settings of the config file
+// Fields left out of the file keep their default value
+type circuitBreakerFileConfig struct {
+	FailureThreshold     *int     `json:"failure_threshold"`
+	FailureRateThreshold *float64 `json:"failure_rate_threshold"`
+	SlowCallDuration     *string  `json:"slow_call_duration"`
+	SlowCallRate         *float64 `json:"slow_call_rate_threshold"`
+	FailureWindow        *string  `json:"failure_window"`
+	SuccessThreshold     *int     `json:"success_threshold"`
+	HalfOpenMaxProbes    *int     `json:"half_open_max_probes"`
+	OpenTimeout          *string  `json:"open_timeout"`
+	MinimumRequests      *int     `json:"minimum_requests"`
+}
+
+// bulkheadFileConfig holds the bulkhead settings of the config file
+// Fields left out of the file keep their default value
+type bulkheadFileConfig struct {
+	MaxConcurrent *int    `json:"max_concurrent"`
+	MaxQueue      *int    `json:"max_queue"`
+	MaxWait       *string `json:"max_wait"`
+}
+
+// adaptiveLimiterFileConfig holds the adaptive limiter settings of the config file
+// Fields left out of the file keep their default value
+type adaptiveLimiterFileConfig struct {
+	Enabled      *bool   `json:"enabled"`
+	Algorithm    *string `json:"algorithm"`
+	InitialLimit *int    `json:"initial_limit"`
+	MinLimit     *int    `json:"min_limit"`
+	MaxLimit     *int    `json:"max_limit"`
+}
+
+// retryFileConfig holds the retry settings of the config file
+// Fields left out of the file keep their default value
+type retryFileConfig struct {
+	MaxAttempts    *int     `json:"max_attempts"`
+	BaseBackoff    *string  `json:"base_backoff"`
+	MaxBackoff     *string  `json:"max_backoff"`
+	BudgetRatio    *float64 `json:"budget_ratio"`
+	BudgetCapacity *int     `json:"budget_capacity"`
+}
+
+// idempotencyFileConfig holds the idempotency store settings of the config file
+// Fields left out of the file keep their default value
+type idempotencyFileConfig struct {
+	TTL *string `json:"ttl"`
+}
+
+// hedgeFileConfig holds the hedging settings of the config file
+// Fields left out of the file keep their default value
+type hedgeFileConfig struct {
+	Enabled     *bool    `json:"enabled"`
+	Delay       *string  `json:"delay"`
+	Percentile  *float64 `json:"percentile"`
+	MaxInFlight *int     `json:"max_in_flight"`
+}
+
+// deadlineFileConfig holds the deadline settings of the config file
+// Fields left out of the file keep their default value
+type deadlineFileConfig struct {
+	Timeout *string `json:"timeout"`
+	Floor   *string `json:"floor"`
+}
+
+// fallbackFileConfig holds the fallback settings of the config file
+// Fields left out of the file keep their default value
+type fallbackFileConfig struct {
+	OnCircuitOpen  *bool   `json:"on_circuit_open"`
+	OnBulkheadFull *bool   `json:"on_bulkhead_full"`
+	OnTimeout      *bool   `json:"on_timeout"`
+	OnServerError  *bool   `json:"on_server_error"`
+	RetryInterval  *string `json:"retry_interval"`
+	MaxAttempts    *int    `json:"max_attempts"`
+}
+
+// cacheFileConfig holds the response cache settings of the config file
+// Fields left out of the file keep their default value
+type cacheFileConfig struct {
+	MaxEntries *int    `json:"max_entries"`
+	TTL        *string `json:"ttl"`
+	MaxStale   *string `json:"max_stale"`
+}
+
+// loadShedderFileConfig holds the load shedding settings of the config file
+// Fields left out of the file keep their default value
+type loadShedderFileConfig struct {
+	MaxInFlight *int    `json:"max_in_flight"`
+	MaxQueue    *int    `json:"max_queue"`
+	Target      *string `json:"target"`
+	Interval    *string `json:"interval"`
+}
+
+// rateLimitFileConfig holds the rate limit of a route in the config file
+// Fields left out of the file keep their default value
+type rateLimitFileConfig struct {
+	Rate  *float64 `json:"rate"`
+	Burst *int     `json:"burst"`
+	Key   *string  `json:"key"`
+}
+
+// LoadCircuitBreakerConfig builds the configuration of the named circuit breaker
+// Values are layered: defaults, then the file named by RESILIENCE_CONFIG_FILE,
+// then environment variables such as CIRCUIT_BREAKER_PAYMENT_FAILURE_THRESHOLD
+func LoadCircuitBreakerConfig(name string, defaults CircuitBreakerConfig) (CircuitBreakerConfig, error) {
+	return loadConfig("circuit breaker", "CIRCUIT_BREAKER", name, defaults,
+		func(f *fileConfig) map[string]circuitBreakerFileConfig { return f.CircuitBreakers }, applyCircuitBreakerEnv)
+}
+
+// LoadBulkheadConfig builds the configuration of the named bulkhead
+// Values are layered: defaults, then the file named by RESILIENCE_CONFIG_FILE,
+// then environment variables such as BULKHEAD_PAYMENT_MAX_CONCURRENT
+func LoadBulkheadConfig(name string, defaults BulkheadConfig) (BulkheadConfig, error) {
+	return loadConfig("bulkhead", "BULKHEAD", name, defaults,
+		func(f *fileConfig) map[string]bulkheadFileConfig { return f.Bulkheads }, applyBulkheadEnv)
+}
+
+// LoadAdaptiveLimiterConfig builds the configuration of the named adaptive limiter
+// Values are layered: defaults, then the file named by RESILIENCE_CONFIG_FILE,
+// then environment variables such as ADAPTIVE_LIMITER_PAYMENT_ALGORITHM
+func LoadAdaptiveLimiterConfig(name string, defaults AdaptiveLimiterConfig) (AdaptiveLimiterConfig, error) {
+	return loadConfig("adaptive limiter", "ADAPTIVE_LIMITER", name, defaults,
+		func(f *fileConfig) map[string]adaptiveLimiterFileConfig { return f.AdaptiveLimiters }, applyAdaptiveLimiterEnv)
+}
+
+// LoadRetryConfig builds the configuration of the named retry policy
+// Values are layered: defaults, then the file named by RESILIENCE_CONFIG_FILE,
+// then environment variables such as RETRY_PAYMENT_MAX_ATTEMPTS
+func LoadRetryConfig(name string, defaults RetryConfig) (RetryConfig, error) {
+	return loadConfig("retry", "RETRY", name, defaults,
+		func(f *fileConfig) map[string]retryFileConfig { return f.Retries }, applyRetryEnv)
+}
+
+// LoadIdempotencyConfig builds the configuration of the named idempotency store
+// Values are layered: defaults, then the file named by RESILIENCE_CONFIG_FILE,
+// then environment variables such as IDEMPOTENCY_PAYMENTS_TTL
+func LoadIdempotencyConfig(name string, defaults IdempotencyConfig) (IdempotencyConfig, error) {
+	return loadConfig("idempotency", "IDEMPOTENCY", name, defaults,
+		func(f *fileConfig) map[string]idempotencyFileConfig { return f.Idempotency }, applyIdempotencyEnv)
+}
+
+// LoadHedgeConfig builds the configuration of the named hedging policy
+// Values are layered: defaults, then the file named by RESILIENCE_CONFIG_FILE,
+// then environment variables such as HEDGE_ORDER_ENABLED
+func LoadHedgeConfig(name string, defaults HedgeConfig) (HedgeConfig, error) {
+	return loadConfig("hedge", "HEDGE", name, defaults,
+		func(f *fileConfig) map[string]hedgeFileConfig { return f.Hedges }, applyHedgeEnv)
+}
+
+// LoadDeadlineConfig builds the deadline configuration of the named service
+// Values are layered: defaults, then the file named by RESILIENCE_CONFIG_FILE,
+// then environment variables such as DEADLINE_API_GATEWAY_TIMEOUT
+func LoadDeadlineConfig(name string, defaults DeadlineConfig) (DeadlineConfig, error) {
+	return loadConfig("deadline", "DEADLINE", name, defaults,
+		func(f *fileConfig) map[string]deadlineFileConfig { return f.Deadlines }, applyDeadlineEnv)
+}
+
+// LoadFallbackConfig builds the configuration of the named fallback
+// Values are layered: defaults, then the file named by RESILIENCE_CONFIG_FILE,
+// then environment variables such as FALLBACK_PAYMENT_ON_TIMEOUT
+func LoadFallbackConfig(name string, defaults FallbackConfig) (FallbackConfig, error) {
+	return loadConfig("fallback", "FALLBACK", name, defaults,
+		func(f *fileConfig) map[string]fallbackFileConfig { return f.Fallbacks }, applyFallbackEnv)
+}
+
+// LoadCacheConfig builds the configuration of the named response cache
+// Values are layered: defaults, then the file named by RESILIENCE_CONFIG_FILE,
+// then environment variables such as CACHE_ORDER_TTL
+func LoadCacheConfig(name string, defaults CacheConfig) (CacheConfig, error) {
+	return loadConfig("cache", "CACHE", name, defaults,
+		func(f *fileConfig) map[string]cacheFileConfig { return f.Caches }, applyCacheEnv)
+}
+
+// LoadLoadShedderConfig builds the load shedding configuration of the named service
+// Values are layered: defaults, then the file named by RESILIENCE_CONFIG_FILE,
+// then environment variables such as LOAD_SHEDDER_ORDER_SERVICE_MAX_IN_FLIGHT
+func LoadLoadShedderConfig(name string, defaults LoadShedderConfig) (LoadShedderConfig, error) {
+	return loadConfig("load shedder", "LOAD_SHEDDER", name, defaults,
+		func(f *fileConfig) map[string]loadShedderFileConfig { return f.LoadShedders }, applyLoadShedderEnv)
+}
+
+// LoadRateLimitConfig builds the rate limits of a service
+// Routes in the file named by RESILIENCE_CONFIG_FILE override or add to the defaults
+// Call it again to pick up changes to the file
+func LoadRateLimitConfig(defaults RateLimitConfig) (RateLimitConfig, error) {
+	cfg := RateLimitConfig{Routes: make(map[string]RateLimitRule, len(defaults.Routes))}
+	for route, rule := range defaults.Routes {
+		cfg.Routes[route] = rule
+	}
+
+	// Unlike the other settings rate limits can be reloaded, so read the file afresh
+	file, _, err := configFile(true)
+	if err != nil {
+		return cfg, err
+	}
+	if file != nil {
+		for route, fc := range file.RateLimits {
+			rule := cfg.Routes[route]
+			if rule.Key == "" {
+				rule.Key = RateLimitKeyIP
+			}
+			fc.apply(&rule)
+			cfg.Routes[route] = rule
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("rate limits: %w", err)
+	}
+	return cfg, nil
+}
+
+// fileSection is the settings of one named policy in the config file
+type fileSection[C any] interface {
+	apply(cfg *C) error
+}
+
+// loadConfig builds the configuration of the named policy of a kind, layering defaults,
+// its section of the config file and the environment variables under envKind
+func loadConfig[C interface{ Validate() error }, F fileSection[C]](
+	kind, envKind, name string,
+	defaults C,
+	sections func(*fileConfig) map[string]F,
+	applyEnv func(prefix string, cfg *C) error,
+) (C, error) {
+	cfg := defaults
+
+	file, path, err := configFile(false)
+	if err != nil {
+		return cfg, err
+	}
+	if file != nil {
+		if fc, ok := sections(file)[name]; ok {
+			if err := fc.apply(&cfg); err != nil {
+				return cfg, fmt.Errorf("%s %q in %s: %w", kind, name, path, err)
+			}
+		}
+	}
+
+	if err := applyEnv(envPrefix(envKind, name), &cfg); err != nil {
+		return cfg, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s %q: %w", kind, name, err)
+	}
+	return cfg, nil
+}
+
+// parsedConfigFile is the config file parsed by configFile, shared by every loader
+var parsedConfigFile struct {
+	mu   sync.Mutex
+	path string
+	file *fileConfig
+}
+
+// configFile returns the config file named by RESILIENCE_CONFIG_FILE and its path,
+// or nil if none is set
+// The file is parsed once and shared by every loader; reload reads it again
+func configFile(reload bool) (*fileConfig, string, error) {
+	path := os.Getenv(ConfigFileEnv)
+	if path == "" {
+		return nil, "", nil
+	}
+
+	parsedConfigFile.mu.Lock()
+	defer parsedConfigFile.mu.Unlock()
+	if !reload && parsedConfigFile.file != nil && parsedConfigFile.path == path {
+		return parsedConfigFile.file, path, nil
+	}
+
+	file, err := readConfigFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+	parsedConfigFile.path, parsedConfigFile.file = path, file
+	return file, path, nil
+}
+
+// readConfigFile parses the JSON config file at path
+func readConfigFile(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file fileConfig
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// apply copies the settings present in the file onto cfg
+func (fc circuitBreakerFileConfig) apply(cfg *CircuitBreakerConfig) error {
+	if fc.FailureThreshold != nil {
+		cfg.FailureThreshold = *fc.FailureThreshold
+	}
+	if fc.FailureRateThreshold != nil {
+		cfg.FailureRateThreshold = *fc.FailureRateThreshold
+	}
+	if fc.SlowCallDuration != nil {
+		d, err := time.ParseDuration(*fc.SlowCallDuration)
+		if err != nil {
+			return fmt.Errorf("invalid slow_call_duration: %w", err)
+		}
+		cfg.SlowCallDuration = d
+	}
+	if fc.SlowCallRate != nil {
+		cfg.SlowCallRateThreshold = *fc.SlowCallRate
+	}
+	if fc.FailureWindow != nil {
+		d, err := time.ParseDuration(*fc.FailureWindow)
+		if err != nil {
+			return fmt.Errorf("invalid failure_window: %w", err)
+		}
+		cfg.FailureWindow = d
+	}
+	if fc.SuccessThreshold != nil {
+		cfg.SuccessThreshold = *fc.SuccessThreshold
+	}
+	if fc.HalfOpenMaxProbes != nil {
+		cfg.HalfOpenMaxProbes = *fc.HalfOpenMaxProbes
+	}
+	if fc.OpenTimeout != nil {
+		d, err := time.ParseDuration(*fc.OpenTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid open_timeout: %w", err)
+		}
+		cfg.OpenTimeout = d
+	}
+	if fc.MinimumRequests != nil {
+		cfg.MinimumRequests = *fc.MinimumRequests
+	}
+	return nil
+}
+
+// apply copies the settings present in the file onto cfg
+func (fc bulkheadFileConfig) apply(cfg *BulkheadConfig) error {
+	if fc.MaxConcurrent != nil {
+		cfg.MaxConcurrent = *fc.MaxConcurrent
+	}
+	if fc.MaxQueue != nil {
+		cfg.MaxQueue = *fc.MaxQueue
+	}
+	if fc.MaxWait != nil {
+		d, err := time.ParseDuration(*fc.MaxWait)
+		if err != nil {
+			return fmt.Errorf("invalid max_wait: %w", err)
+		}
+		cfg.MaxWait = d
+	}
+	return nil
+}
+
+// apply copies the settings present in the file onto cfg
+func (fc adaptiveLimiterFileConfig) apply(cfg *AdaptiveLimiterConfig) error {
+	if fc.Enabled != nil {
+		cfg.Enabled = *fc.Enabled
+	}
+	if fc.Algorithm != nil {
+		cfg.Algorithm = *fc.Algorithm
+	}
+	if fc.InitialLimit != nil {
+		cfg.InitialLimit = *fc.InitialLimit
+	}
+	if fc.MinLimit != nil {
+		cfg.MinLimit = *fc.MinLimit
+	}
+	if fc.MaxLimit != nil {
+		cfg.MaxLimit = *fc.MaxLimit
+	}
+	return nil
+}
+
+// apply copies the settings present in the file onto cfg
+func (fc retryFileConfig) apply(cfg *RetryConfig) error {
+	if fc.MaxAttempts != nil {
+		cfg.MaxAttempts = *fc.MaxAttempts
+	}
+	if fc.BaseBackoff != nil {
+		d, err := time.ParseDuration(*fc.BaseBackoff)
+		if err != nil {
+			return fmt.Errorf("invalid base_backoff: %w", err)
+		}
+		cfg.BaseBackoff = d
+	}
+	if fc.MaxBackoff != nil {
+		d, err := time.ParseDuration(*fc.MaxBackoff)
+		if err != nil {
+			return fmt.Errorf("invalid max_backoff: %w", err)
+		}
+		cfg.MaxBackoff = d
+	}
+	if fc.BudgetRatio != nil {
+		cfg.BudgetRatio = *fc.BudgetRatio
+	}
+	if fc.BudgetCapacity != nil {
+		cfg.BudgetCapacity = *fc.BudgetCapacity
+	}
+	return nil
+}
+
+// apply copies the settings present in the file onto cfg
+func (fc idempotencyFileConfig) apply(cfg *IdempotencyConfig) error {
+	if fc.TTL != nil {
+		d, err := time.ParseDuration(*fc.TTL)
+		if err != nil {
+			return fmt.Errorf("invalid ttl: %w", err)
+		}
+		cfg.TTL = d
+	}
+	return nil
+}
+
+// apply copies the settings present in the file onto cfg
+func (fc hedgeFileConfig) apply(cfg *HedgeConfig) error {
+	if fc.Enabled != nil {
+		cfg.Enabled = *fc.Enabled
+	}
+	if fc.Delay != nil {
+		d, err := time.ParseDuration(*fc.Delay)
+		if err != nil {
+			return fmt.Errorf("invalid delay: %w", err)
+		}
+		cfg.Delay = d
+	}
+	if fc.Percentile != nil {
+		cfg.Percentile = *fc.Percentile
+	}
+	if fc.MaxInFlight != nil {
+		cfg.MaxInFlight = *fc.MaxInFlight
+	}
+	return nil
+}
+
+// apply copies the settings present in the file onto cfg
+func (fc deadlineFileConfig) apply(cfg *DeadlineConfig) error {
+	if fc.Timeout != nil {
+		d, err := time.ParseDuration(*fc.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %w", err)
+		}
+		cfg.Timeout = d
+	}
+	if fc.Floor != nil {
+		d, err := time.ParseDuration(*fc.Floor)
+		if err != nil {
+			return fmt.Errorf("invalid floor: %w", err)
+		}
+		cfg.Floor = d
+	}
+	return nil
+}
+
+// apply copies the settings present in the file onto cfg
+func (fc fallbackFileConfig) apply(cfg *FallbackConfig) error {
+	if fc.OnCircuitOpen != nil {
+		cfg.OnCircuitOpen = *fc.OnCircuitOpen
+	}
+	if fc.OnBulkheadFull != nil {
+		cfg.OnBulkheadFull = *fc.OnBulkheadFull
+	}
+	if fc.OnTimeout != nil {
+		cfg.OnTimeout = *fc.OnTimeout
+	}
+	if fc.OnServerError != nil {
+		cfg.OnServerError = *fc.OnServerError
+	}
+	if fc.RetryInterval != nil {
+		d, err := time.ParseDuration(*fc.RetryInterval)
+		if err != nil {
+			return fmt.Errorf("invalid retry interval: %w", err)
+		}
+		cfg.RetryInterval = d
+	}
+	if fc.MaxAttempts != nil {
+		cfg.MaxAttempts = *fc.MaxAttempts
+	}
+	return nil
+}
+
+// apply copies the settings present in the file onto cfg
+func (fc cacheFileConfig) apply(cfg *CacheConfig) error {
+	if fc.MaxEntries != nil {
+		cfg.MaxEntries = *fc.MaxEntries
+	}
+	if fc.TTL != nil {
+		d, err := time.ParseDuration(*fc.TTL)
+		if err != nil {
+			return fmt.Errorf("invalid ttl: %w", err)
+		}
+		cfg.TTL = d
+	}
+	if fc.MaxStale != nil {
+		d, err := time.ParseDuration(*fc.MaxStale)
+		if err != nil {
+			return fmt.Errorf("invalid max stale: %w", err)
+		}
+		cfg.MaxStale = d
+	}
+	return nil
+}
+
+// apply copies the settings present in the file onto cfg
+func (fc loadShedderFileConfig) apply(cfg *LoadShedderConfig) error {
+	if fc.MaxInFlight != nil {
+		cfg.MaxInFlight = *fc.MaxInFlight
+	}
+	if fc.MaxQueue != nil {
+		cfg.MaxQueue = *fc.MaxQueue
+	}
+	if fc.Target != nil {
+		d, err := time.ParseDuration(*fc.Target)
+		if err != nil {
+			return fmt.Errorf("invalid target: %w", err)
+		}
+		cfg.Target = d
+	}
+	if fc.Interval != nil {
+		d, err := time.ParseDuration(*fc.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid interval: %w", err)
+		}
+		cfg.Interval = d
+	}
+	return nil
+}
+
+// apply copies the settings present in the file onto rule
+func (fc rateLimitFileConfig) apply(rule *RateLimitRule) {
+	if fc.Rate != nil {
+		rule.Rate = *fc.Rate
+	}
+	if fc.Burst != nil {
+		rule.Burst = *fc.Burst
+	}
+	if fc.Key != nil {
+		rule.Key = *fc.Key
+	}
+}
+
+// applyCircuitBreakerEnv overrides cfg with the environment variables under prefix
+func applyCircuitBreakerEnv(prefix string, cfg *CircuitBreakerConfig) error {
+	if err := envInt(prefix+"FAILURE_THRESHOLD", &cfg.FailureThreshold); err != nil {
+		return err
+	}
+	if err := envFloat(prefix+"FAILURE_RATE_THRESHOLD", &cfg.FailureRateThreshold); err != nil {
+		return err
+	}
+	if err := envDuration(prefix+"SLOW_CALL_DURATION", &cfg.SlowCallDuration); err != nil {
+		return err
+	}
+	if err := envFloat(prefix+"SLOW_CALL_RATE_THRESHOLD", &cfg.SlowCallRateThreshold); err != nil {
+		return err
+	}
+	if err := envDuration(prefix+"FAILURE_WINDOW", &cfg.FailureWindow); err != nil {
+		return err
+	}
+	if err := envInt(prefix+"SUCCESS_THRESHOLD", &cfg.SuccessThreshold); err != nil {
+		return err
+	}
+	if err := envInt(prefix+"HALF_OPEN_MAX_PROBES", &cfg.HalfOpenMaxProbes); err != nil {
+		return err
+	}
+	if err := envDuration(prefix+"OPEN_TIMEOUT", &cfg.OpenTimeout); err != nil {
+		return err
+	}
+	return envInt(prefix+"MINIMUM_REQUESTS", &cfg.MinimumRequests)
+}
+
+// applyBulkheadEnv overrides cfg with the environment variables under prefix
+func applyBulkheadEnv(prefix string, cfg *BulkheadConfig) error {
+	if err := envInt(prefix+"MAX_CONCURRENT", &cfg.MaxConcurrent); err != nil {
+		return err
+	}
+	if err := envInt(prefix+"MAX_QUEUE", &cfg.MaxQueue); err != nil {
+		return err
+	}
+	return envDuration(prefix+"MAX_WAIT", &cfg.MaxWait)
+}
+
+// applyAdaptiveLimiterEnv overrides cfg with the environment variables under prefix
+func applyAdaptiveLimiterEnv(prefix string, cfg *AdaptiveLimiterConfig) error {
+	if err := envBool(prefix+"ENABLED", &cfg.Enabled); err != nil {
+		return err
+	}
+	if v := os.Getenv(prefix + "ALGORITHM"); v != "" {
+		cfg.Algorithm = strings.ToLower(v)
+	}
+	if err := envInt(prefix+"INITIAL_LIMIT", &cfg.InitialLimit); err != nil {
+		return err
+	}
+	if err := envInt(prefix+"MIN_LIMIT", &cfg.MinLimit); err != nil {
+		return err
+	}
+	return envInt(prefix+"MAX_LIMIT", &cfg.MaxLimit)
+}
+
+// applyRetryEnv overrides cfg with the environment variables under prefix
+func applyRetryEnv(prefix string, cfg *RetryConfig) error {
+	if err := envInt(prefix+"MAX_ATTEMPTS", &cfg.MaxAttempts); err != nil {
+		return err
+	}
+	if err := envDuration(prefix+"BASE_BACKOFF", &cfg.BaseBackoff); err != nil {
+		return err
+	}
+	if err := envDuration(prefix+"MAX_BACKOFF", &cfg.MaxBackoff); err != nil {
+		return err
+	}
+	if err := envFloat(prefix+"BUDGET_RATIO", &cfg.BudgetRatio); err != nil {
+		return err
+	}
+	return envInt(prefix+"BUDGET_CAPACITY", &cfg.BudgetCapacity)
+}
+
+// applyIdempotencyEnv overrides cfg with the environment variables under prefix
+func applyIdempotencyEnv(prefix string, cfg *IdempotencyConfig) error {
+	return envDuration(prefix+"TTL", &cfg.TTL)
+}
+
+// applyHedgeEnv overrides cfg with the environment variables under prefix
+func applyHedgeEnv(prefix string, cfg *HedgeConfig) error {
+	if err := envBool(prefix+"ENABLED", &cfg.Enabled); err != nil {
+		return err
+	}
+	if err := envDuration(prefix+"DELAY", &cfg.Delay); err != nil {
+		return err
+	}
+	if err := envFloat(prefix+"PERCENTILE", &cfg.Percentile); err != nil {
+		return err
+	}
+	return envInt(prefix+"MAX_IN_FLIGHT", &cfg.MaxInFlight)
+}
+
+// applyDeadlineEnv overrides cfg with the environment variables under prefix
+func applyDeadlineEnv(prefix string, cfg *DeadlineConfig) error {
+	if err := envDuration(prefix+"TIMEOUT", &cfg.Timeout); err != nil {
+		return err
+	}
+	return envDuration(prefix+"FLOOR", &cfg.Floor)
+}
+
+// applyFallbackEnv overrides cfg with the environment variables under prefix
+func applyFallbackEnv(prefix string, cfg *FallbackConfig) error {
+	if err := envBool(prefix+"ON_CIRCUIT_OPEN", &cfg.OnCircuitOpen); err != nil {
+		return err
+	}
+	if err := envBool(prefix+"ON_BULKHEAD_FULL", &cfg.OnBulkheadFull); err != nil {
+		return err
+	}
+	if err := envBool(prefix+"ON_TIMEOUT", &cfg.OnTimeout); err != nil {
+		return err
+	}
+	if err := envBool(prefix+"ON_SERVER_ERROR", &cfg.OnServerError); err != nil {
+		return err
+	}
+	if err := envDuration(prefix+"RETRY_INTERVAL", &cfg.RetryInterval); err != nil {
+		return err
+	}
+	return envInt(prefix+"MAX_ATTEMPTS", &cfg.MaxAttempts)
+}
+
+// applyCacheEnv overrides cfg with the environment variables under prefix
+func applyCacheEnv(prefix string, cfg *CacheConfig) error {
+	if err := envInt(prefix+"MAX_ENTRIES", &cfg.MaxEntries); err != nil {
+		return err
+	}
+	if err := envDuration(prefix+"TTL", &cfg.TTL); err != nil {
+		return err
+	}
+	return envDuration(prefix+"MAX_STALE", &cfg.MaxStale)
+}
+
+// applyLoadShedderEnv overrides cfg with the environment variables under prefix
+func applyLoadShedderEnv(prefix string, cfg *LoadShedderConfig) error {
+	if err := envInt(prefix+"MAX_IN_FLIGHT", &cfg.MaxInFlight); err != nil {
+		return err
+	}
+	if err := envInt(prefix+"MAX_QUEUE", &cfg.MaxQueue); err != nil {
+		return err
+	}
+	if err := envDuration(prefix+"TARGET", &cfg.Target); err != nil {
+		return err
+	}
+	return envDuration(prefix+"INTERVAL", &cfg.Interval)
+}
+
+// envPrefix builds the environment variable prefix for a named policy
+// e.g. ("CIRCUIT_BREAKER", "payment") -> "CIRCUIT_BREAKER_PAYMENT_"
+func envPrefix(kind, name string) string {
+	name = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+	return kind + "_" + name + "_"
+}
+
+// envInt sets dst from the integer environment variable key if it is set
+func envInt(key string, dst *int) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	*dst = n
+	return nil
+}
+
+// envBool sets dst from the boolean environment variable key if it is set
+func envBool(key string, dst *bool) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	*dst = b
+	return nil
+}
+
+// envFloat sets dst from the floating point environment variable key if it is set
+func envFloat(key string, dst *float64) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	*dst = f
+	return nil
+}
+
+// envDuration sets dst from the duration environment variable key if it is set
+func envDuration(key string, dst *time.Duration) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	*dst = d
+	return nil
+}
@@ -0,0 +1,193 @@
+package resilience
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadCircuitBreakerConfigDefaults(t *testing.T) {
+	t.Setenv(ConfigFileEnv, "")
+
+	cfg, err := LoadCircuitBreakerConfig("defaults", DefaultCircuitBreakerConfig())
+	if err != nil {
+		t.Fatalf("LoadCircuitBreakerConfig: %v", err)
+	}
+	if cfg != DefaultCircuitBreakerConfig() {
+		t.Fatalf("cfg = %+v, want defaults", cfg)
+	}
+}
+
+func TestLoadCircuitBreakerConfigFileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resilience.json")
+	data := `{"circuit_breakers": {"payment-api": {"failure_threshold": 8, "failure_window": "20s", "open_timeout": "1m"}}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ConfigFileEnv, path)
+	t.Setenv("CIRCUIT_BREAKER_PAYMENT_API_OPEN_TIMEOUT", "45s")
+	t.Setenv("CIRCUIT_BREAKER_PAYMENT_API_MINIMUM_REQUESTS", "20")
+
+	cfg, err := LoadCircuitBreakerConfig("payment-api", DefaultCircuitBreakerConfig())
+	if err != nil {
+		t.Fatalf("LoadCircuitBreakerConfig: %v", err)
+	}
+
+	want := DefaultCircuitBreakerConfig()
+	want.FailureThreshold = 8
+	want.FailureWindow = 20 * time.Second
+	want.OpenTimeout = 45 * time.Second
+	want.MinimumRequests = 20
+	if cfg != want {
+		t.Fatalf("cfg = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestLoadCircuitBreakerConfigInvalid(t *testing.T) {
+	t.Setenv(ConfigFileEnv, "")
+
+	t.Setenv("CIRCUIT_BREAKER_BROKEN_FAILURE_WINDOW", "soon")
+	if _, err := LoadCircuitBreakerConfig("broken", DefaultCircuitBreakerConfig()); err == nil {
+		t.Fatal("expected error for unparsable duration")
+	}
+
+	t.Setenv("CIRCUIT_BREAKER_BROKEN_FAILURE_WINDOW", "")
+	t.Setenv("CIRCUIT_BREAKER_BROKEN_FAILURE_THRESHOLD", "0")
+	if _, err := LoadCircuitBreakerConfig("broken", DefaultCircuitBreakerConfig()); err == nil {
+		t.Fatal("expected error for zero failure threshold")
+	}
+}
+
+func TestLoadBulkheadConfigFileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resilience.json")
+	data := `{"bulkheads": {"payment": {"max_concurrent": 4, "max_queue": 8, "max_wait": "250ms"}}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ConfigFileEnv, path)
+	t.Setenv("BULKHEAD_PAYMENT_MAX_WAIT", "1s")
+
+	cfg, err := LoadBulkheadConfig("payment", DefaultBulkheadConfig())
+	if err != nil {
+		t.Fatalf("LoadBulkheadConfig: %v", err)
+	}
+
+	want := BulkheadConfig{MaxConcurrent: 4, MaxQueue: 8, MaxWait: time.Second}
+	if cfg != want {
+		t.Fatalf("cfg = %+v, want %+v", cfg, want)
+	}
+
+	t.Setenv("BULKHEAD_PAYMENT_MAX_QUEUE", "-1")
+	if _, err := LoadBulkheadConfig("payment", DefaultBulkheadConfig()); err == nil {
+		t.Fatal("expected error for negative queue size")
+	}
+}
+
+func TestLoadAdaptiveLimiterConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resilience.json")
+	data := `{"adaptive_limiters": {"payment": {"enabled": true, "algorithm": "gradient", "max_limit": 50}}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ConfigFileEnv, path)
+	t.Setenv("ADAPTIVE_LIMITER_PAYMENT_ALGORITHM", "Vegas")
+
+	cfg, err := LoadAdaptiveLimiterConfig("payment", DefaultAdaptiveLimiterConfig())
+	if err != nil {
+		t.Fatalf("LoadAdaptiveLimiterConfig: %v", err)
+	}
+
+	want := DefaultAdaptiveLimiterConfig()
+	want.Enabled = true
+	want.Algorithm = LimitAlgorithmVegas
+	want.MaxLimit = 50
+	if cfg != want {
+		t.Fatalf("cfg = %+v, want %+v", cfg, want)
+	}
+
+	t.Setenv("ADAPTIVE_LIMITER_PAYMENT_ALGORITHM", "random")
+	if _, err := LoadAdaptiveLimiterConfig("payment", DefaultAdaptiveLimiterConfig()); err == nil {
+		t.Fatal("expected error for unknown algorithm")
+	}
+}
+
+func TestLoadRetryConfig(t *testing.T) {
+	t.Setenv(ConfigFileEnv, "")
+	t.Setenv("RETRY_ORDER_MAX_ATTEMPTS", "4")
+	t.Setenv("RETRY_ORDER_MAX_BACKOFF", "2s")
+
+	cfg, err := LoadRetryConfig("order", DefaultRetryConfig())
+	if err != nil {
+		t.Fatalf("LoadRetryConfig: %v", err)
+	}
+
+	want := DefaultRetryConfig()
+	want.MaxAttempts = 4
+	want.MaxBackoff = 2 * time.Second
+	if cfg != want {
+		t.Fatalf("cfg = %+v, want %+v", cfg, want)
+	}
+
+	t.Setenv("RETRY_ORDER_MAX_BACKOFF", "1ms")
+	if _, err := LoadRetryConfig("order", DefaultRetryConfig()); err == nil {
+		t.Fatal("expected error for max backoff below base backoff")
+	}
+}
+
+func TestLoadRateLimitConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resilience.json")
+	data := `{"rate_limits": {"POST /api/orders": {"rate": 2}, "GET /api/orders/:id": {"rate": 50, "burst": 100}}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ConfigFileEnv, path)
+
+	defaults := RateLimitConfig{Routes: map[string]RateLimitRule{
+		"POST /api/orders": {Rate: 10, Burst: 20, Key: RateLimitKeyCustomerID},
+	}}
+	cfg, err := LoadRateLimitConfig(defaults)
+	if err != nil {
+		t.Fatalf("LoadRateLimitConfig: %v", err)
+	}
+
+	if got, want := cfg.Routes["POST /api/orders"], (RateLimitRule{Rate: 2, Burst: 20, Key: RateLimitKeyCustomerID}); got != want {
+		t.Fatalf("POST rule = %+v, want %+v", got, want)
+	}
+	if got, want := cfg.Routes["GET /api/orders/:id"], (RateLimitRule{Rate: 50, Burst: 100, Key: RateLimitKeyIP}); got != want {
+		t.Fatalf("GET rule = %+v, want %+v", got, want)
+	}
+	if defaults.Routes["POST /api/orders"].Rate != 10 {
+		t.Fatal("defaults were modified")
+	}
+}
+
+func TestConfigFileParsedOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resilience.json")
+	data := `{"bulkheads": {"payment": {"max_concurrent": 7}}, "retries": {"payment": {"max_attempts": 5}}, "rate_limits": {"POST /api/orders": {"rate": 2}}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ConfigFileEnv, path)
+
+	if _, err := LoadBulkheadConfig("payment", BulkheadConfig{MaxConcurrent: 1, MaxWait: time.Second}); err != nil {
+		t.Fatalf("LoadBulkheadConfig: %v", err)
+	}
+
+	// Later loaders take their section from the parsed file instead of reading it again
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadRetryConfig("payment", RetryConfig{MaxAttempts: 3, BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+	if err != nil {
+		t.Fatalf("LoadRetryConfig: %v", err)
+	}
+	if cfg.MaxAttempts != 5 {
+		t.Fatalf("MaxAttempts = %d, want 5", cfg.MaxAttempts)
+	}
+
+	// Rate limits can be reloaded, so they read the file afresh
+	if _, err := LoadRateLimitConfig(RateLimitConfig{}); err == nil {
+		t.Fatal("LoadRateLimitConfig read a removed file without error")
+	}
+}
@@ -0,0 +1,60 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DeadlineHeader carries the time a caller is still willing to wait for a response
+// The value is the remaining budget in whole milliseconds, relative rather than an
+// absolute time so hosts don't need synchronized clocks
+const DeadlineHeader = "X-Request-Deadline"
+
+// ErrDeadlineTooShort is returned when the remaining budget of a request is below the floor
+var ErrDeadlineTooShort = errors.New("remaining deadline budget is too short")
+
+// SetDeadlineHeader copies the remaining budget of the request's context into its headers
+// Requests without a deadline, or whose deadline already passed, are left unchanged
+func SetDeadlineHeader(req *http.Request) {
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return
+	}
+	if remaining := time.Until(deadline).Milliseconds(); remaining > 0 {
+		req.Header.Set(DeadlineHeader, strconv.FormatInt(remaining, 10))
+	}
+}
+
+// RequestBudget decides how long an incoming request may take
+// header is the value of DeadlineHeader, if any; cfg.Timeout caps it and applies
+// when the header is missing. ok is false when neither sets a deadline
+// It returns ErrDeadlineTooShort if the budget is below cfg.Floor
+func RequestBudget(header string, cfg DeadlineConfig) (budget time.Duration, ok bool, err error) {
+	budget = cfg.Timeout
+	if header != "" {
+		ms, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || ms < 0 {
+			return 0, false, fmt.Errorf("invalid %s header %q", DeadlineHeader, header)
+		}
+		if fromHeader := time.Duration(ms) * time.Millisecond; budget <= 0 || fromHeader < budget {
+			budget = fromHeader
+		}
+	} else if budget <= 0 {
+		return 0, false, nil
+	}
+
+	if budget < cfg.Floor {
+		return budget, true, fmt.Errorf("%w: %v left, need at least %v", ErrDeadlineTooShort, budget, cfg.Floor)
+	}
+	return budget, true, nil
+}
+
+// outlives reports whether ctx is still live after d
+func outlives(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > d
+}
@@ -0,0 +1,79 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRequestBudget(t *testing.T) {
+	gateway := DeadlineConfig{Timeout: 5 * time.Second, Floor: 50 * time.Millisecond}
+	downstream := DefaultDeadlineConfig()
+
+	tests := []struct {
+		name       string
+		header     string
+		cfg        DeadlineConfig
+		wantBudget time.Duration
+		wantOK     bool
+		wantErr    error
+	}{
+		{"gateway default", "", gateway, 5 * time.Second, true, nil},
+		{"header shorter than timeout", "2000", gateway, 2 * time.Second, true, nil},
+		{"header capped by timeout", "60000", gateway, 5 * time.Second, true, nil},
+		{"downstream without header", "", downstream, 0, false, nil},
+		{"downstream with header", "1500", downstream, 1500 * time.Millisecond, true, nil},
+		{"below floor", "10", downstream, 10 * time.Millisecond, true, ErrDeadlineTooShort},
+	}
+	for _, tt := range tests {
+		budget, ok, err := RequestBudget(tt.header, tt.cfg)
+		if budget != tt.wantBudget || ok != tt.wantOK || !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: RequestBudget = %v, %v, %v, want %v, %v, %v", tt.name, budget, ok, err, tt.wantBudget, tt.wantOK, tt.wantErr)
+		}
+	}
+
+	if _, _, err := RequestBudget("soon", downstream); err == nil {
+		t.Error("expected error for malformed header")
+	}
+}
+
+func TestSetDeadlineHeader(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://order-service/api/orders/1", nil)
+	SetDeadlineHeader(req)
+	if got := req.Header.Get(DeadlineHeader); got != "" {
+		t.Fatalf("header without deadline = %q, want none", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req = req.WithContext(ctx)
+	SetDeadlineHeader(req)
+
+	ms, err := strconv.Atoi(req.Header.Get(DeadlineHeader))
+	if err != nil || ms <= 1900 || ms > 2000 {
+		t.Fatalf("header = %q, want about 2000", req.Header.Get(DeadlineHeader))
+	}
+}
+
+func TestRetryStopsWhenDeadlineIsTooClose(t *testing.T) {
+	r := NewRetry("test-retry-deadline", WithBackoff(time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	start := time.Now()
+	_ = r.Do(ctx, func() error {
+		calls++
+		return errRetryable
+	})
+	if calls != 1 {
+		t.Fatalf("%d calls, want 1", calls)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("Do took %v, want it to give up without backing off", elapsed)
+	}
+}
@@ -0,0 +1,83 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+)
+
+// UpstreamError describes a failed call to a downstream service
+type UpstreamError struct {
+	// Service is the name of the service that was called
+	Service string
+	// StatusCode is the HTTP status returned, or 0 if no response was received
+	StatusCode int
+	// Retryable reports whether repeating the call may succeed
+	Retryable bool
+	// Err is the underlying cause
+	Err error
+}
+
+// NewTransportError wraps an error raised before a response was received
+// Transport errors are retryable unless the caller cancelled the request
+func NewTransportError(service string, err error) *UpstreamError {
+	return &UpstreamError{
+		Service:   service,
+		Retryable: !errors.Is(err, context.Canceled),
+		Err:       err,
+	}
+}
+
+// NewStatusError wraps an unexpected HTTP status returned by a service
+// Server errors, 408 and 429 are retryable, other client errors are not
+func NewStatusError(service string, statusCode int, body string) *UpstreamError {
+	err := fmt.Errorf("%s service returned status %d", service, statusCode)
+	if body != "" {
+		err = fmt.Errorf("%w: %s", err, body)
+	}
+	return &UpstreamError{
+		Service:    service,
+		StatusCode: statusCode,
+		Retryable:  statusCode >= 500 || statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests,
+		Err:        err,
+	}
+}
+
+// Error implements the error interface
+func (e *UpstreamError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying cause
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// IsClientError reports whether the service rejected the request itself (4xx)
+func (e *UpstreamError) IsClientError() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500
+}
+
+// DefaultIsFailure decides whether an error counts against a circuit breaker
+// Cancellation by the caller and 4xx rejections say nothing about the health
+// of the service and are ignored; 408 and 429 still count as they signal overload
+func DefaultIsFailure(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) && upstreamErr.IsClientError() {
+		return upstreamErr.Retryable
+	}
+	return true
+}
+
+// IsUnsent reports whether err proves a request never reached the service:
+// a circuit breaker or bulkhead rejected it, or the connection was refused
+// Such requests may be repeated even when they are not idempotent
+func IsUnsent(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrBulkheadFull) || errors.Is(err, syscall.ECONNREFUSED)
+}
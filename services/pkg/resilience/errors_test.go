@@ -0,0 +1,76 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestDefaultIsFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"plain error", errUpstream, true},
+		{"caller cancelled", fmt.Errorf("failed to send request: %w", context.Canceled), false},
+		{"deadline exceeded", context.DeadlineExceeded, true},
+		{"cancelled transport error", NewTransportError("test", context.Canceled), false},
+		{"bad request", NewStatusError("test", 400, ""), false},
+		{"not found", NewStatusError("test", 404, ""), false},
+		{"request timeout", NewStatusError("test", 408, ""), true},
+		{"too many requests", NewStatusError("test", 429, ""), true},
+		{"service unavailable", NewStatusError("test", 503, ""), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultIsFailure(tt.err); got != tt.want {
+				t.Fatalf("DefaultIsFailure(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpstreamErrorUnwrap(t *testing.T) {
+	err := fmt.Errorf("processing payment: %w", NewTransportError("payment", context.DeadlineExceeded))
+
+	var upstreamErr *UpstreamError
+	if !errors.As(err, &upstreamErr) {
+		t.Fatal("errors.As failed to find *UpstreamError")
+	}
+	if !upstreamErr.Retryable {
+		t.Fatal("transport timeout should be retryable")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("errors.Is failed to find the cause")
+	}
+}
+
+func TestIsUnsent(t *testing.T) {
+	refused := NewTransportError("test", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)})
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"circuit open", ErrCircuitOpen, true},
+		{"bulkhead full", ErrBulkheadTimeout, true},
+		{"connection refused", refused, true},
+		{"timeout", NewTransportError("test", context.DeadlineExceeded), false},
+		{"server error", NewStatusError("test", http.StatusServiceUnavailable, ""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsUnsent(tt.err); got != tt.want {
+				t.Fatalf("IsUnsent(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package resilience
+
+import "time"
+
+// defaultEventHistorySize is the number of state changes a circuit breaker remembers
+const defaultEventHistorySize = 100
+
+// String returns the lower-case name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the state by name so it reads well in JSON
+func (s CircuitState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// StateChangeEvent records a single circuit breaker state transition
+type StateChangeEvent struct {
+	Service string       `json:"service"`
+	From    CircuitState `json:"from"`
+	To      CircuitState `json:"to"`
+	Reason  string       `json:"reason"`
+	Time    time.Time    `json:"time"`
+}
+
+// eventHistory keeps the most recent state change events in a fixed-size ring
+// Not safe for concurrent use; callers hold the owning circuit breaker's lock
+type eventHistory struct {
+	events []StateChangeEvent
+	next   int
+	full   bool
+}
+
+// newEventHistory creates a history holding up to size events
+func newEventHistory(size int) *eventHistory {
+	if size < 1 {
+		size = 1
+	}
+	return &eventHistory{events: make([]StateChangeEvent, size)}
+}
+
+// add appends an event, overwriting the oldest once the history is full
+func (h *eventHistory) add(event StateChangeEvent) {
+	h.events[h.next] = event
+	h.next = (h.next + 1) % len(h.events)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// list returns a copy of the stored events, oldest first
+func (h *eventHistory) list() []StateChangeEvent {
+	if !h.full {
+		return append([]StateChangeEvent(nil), h.events[:h.next]...)
+	}
+	out := make([]StateChangeEvent, 0, len(h.events))
+	out = append(out, h.events[h.next:]...)
+	return append(out, h.events[:h.next]...)
+}
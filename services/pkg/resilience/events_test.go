@@ -0,0 +1,105 @@
+package resilience
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCircuitBreakerStateChangeHooks(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-hooks",
+		WithFailureThreshold(1),
+		WithOpenTimeout(10*time.Millisecond),
+	)
+
+	type transition struct{ from, to CircuitState }
+	var got []transition
+	cb.OnStateChange(func(from, to CircuitState) {
+		// Hooks run outside the lock, so calling back in must not deadlock
+		_ = cb.GetState()
+		got = append(got, transition{from, to})
+	})
+
+	failN(cb, 1)
+	time.Sleep(20 * time.Millisecond)
+	_, _ = cb.Execute(func() (int, error) { return 1, nil })
+
+	want := []transition{
+		{StateClosed, StateOpen},
+		{StateOpen, StateHalfOpen},
+		{StateHalfOpen, StateClosed},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("transitions = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("transition %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	events := cb.Events()
+	if len(events) != 3 {
+		t.Fatalf("len(Events()) = %d, want 3", len(events))
+	}
+	if events[0].Reason == "" || events[0].Service != "test-hooks" {
+		t.Fatalf("first event = %+v, want service and reason set", events[0])
+	}
+}
+
+func TestCircuitBreakerSameStateIsNotATransition(t *testing.T) {
+	const name = "test-same-state"
+	cb := NewCircuitBreaker[int](name)
+
+	hooks := 0
+	cb.OnStateChange(func(from, to CircuitState) { hooks++ })
+	closedToClosed := testutil.ToFloat64(circuitBreakerTransitions.WithLabelValues(name, "closed", "closed"))
+	openToOpen := testutil.ToFloat64(circuitBreakerTransitions.WithLabelValues(name, "open", "open"))
+
+	cb.Reset()
+	cb.ForceClose()
+	cb.ForceOpen()
+	cb.ForceOpen()
+
+	if hooks != 1 || len(cb.Events()) != 1 {
+		t.Fatalf("hooks = %d, events = %v, want only the closed->open transition", hooks, cb.Events())
+	}
+	if got := testutil.ToFloat64(circuitBreakerTransitions.WithLabelValues(name, "closed", "closed")) - closedToClosed; got != 0 {
+		t.Fatalf("closed->closed transitions = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(circuitBreakerTransitions.WithLabelValues(name, "open", "open")) - openToOpen; got != 0 {
+		t.Fatalf("open->open transitions = %v, want 0", got)
+	}
+	if snap := cb.Snapshot(); snap.State != StateOpen || !snap.Forced {
+		t.Fatalf("snapshot = %+v, want forced open", snap)
+	}
+}
+
+func TestEventHistoryKeepsMostRecent(t *testing.T) {
+	h := newEventHistory(2)
+	for i := 0; i < 3; i++ {
+		h.add(StateChangeEvent{Reason: string(rune('a' + i))})
+	}
+
+	events := h.list()
+	if len(events) != 2 || events[0].Reason != "b" || events[1].Reason != "c" {
+		t.Fatalf("events = %+v, want [b c]", events)
+	}
+}
+
+func TestStateChangeEventJSON(t *testing.T) {
+	data, err := json.Marshal(StateChangeEvent{From: StateHalfOpen, To: StateOpen})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["from"] != "half-open" || decoded["to"] != "open" {
+		t.Fatalf("encoded = %s, want states by name", data)
+	}
+}
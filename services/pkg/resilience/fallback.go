@@ -0,0 +1,32 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+)
+
+// Covers reports whether the fallback applies to err
+func (c FallbackConfig) Covers(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrCircuitOpen):
+		return c.OnCircuitOpen
+	case errors.Is(err, ErrBulkheadFull):
+		return c.OnBulkheadFull
+	case errors.Is(err, context.DeadlineExceeded):
+		return c.OnTimeout
+	}
+
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) && upstreamErr.Retryable {
+		return c.OnServerError
+	}
+	return false
+}
+
+// IsRejected reports whether err was raised by a policy before the call was made,
+// as by an open circuit or a full bulkhead
+func IsRejected(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrBulkheadFull)
+}
@@ -0,0 +1,35 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFallbackConfigCovers(t *testing.T) {
+	cfg := FallbackConfig{OnCircuitOpen: true, OnServerError: true}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"circuit open", fmt.Errorf("payment: %w", ErrCircuitOpen), true},
+		{"bulkhead full", ErrBulkheadFull, false},
+		{"limit exceeded", ErrLimitExceeded, false},
+		{"timeout", context.DeadlineExceeded, false},
+		{"server error", NewStatusError("payment", 503, ""), true},
+		{"client error", NewStatusError("payment", 400, ""), false},
+		{"cancelled", NewTransportError("payment", context.Canceled), false},
+		{"other", errors.New("boom"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.Covers(tt.err); got != tt.want {
+				t.Fatalf("Covers(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
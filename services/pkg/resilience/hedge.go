@@ -0,0 +1,188 @@
+package resilience
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Latency samples kept to derive the hedge delay from a percentile
+const (
+	hedgeLatencySamples    = 100
+	hedgeMinLatencySamples = 20
+)
+
+var (
+	hedgesSent = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hedged_requests_sent_total",
+			Help: "Total number of hedge requests sent because the first request was slow",
+		},
+		[]string{"service"},
+	)
+
+	hedgesWon = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hedged_requests_won_total",
+			Help: "Total number of hedge requests that answered before the first request",
+		},
+		[]string{"service"},
+	)
+)
+
+// Hedge sends a second request when the first has not answered within a delay
+// and takes whichever answers first, cancelling the other
+// Only use it for idempotent calls, as both requests may reach the service
+type Hedge[T any] struct {
+	mu          sync.Mutex
+	enabled     bool
+	delay       time.Duration
+	percentile  float64
+	maxInFlight int
+	inFlight    int
+	latencies   []time.Duration
+	next        int
+	serviceName string
+}
+
+// NewHedge creates a hedging policy
+// Settings default to DefaultHedgeConfig and can be overridden with options
+func NewHedge[T any](serviceName string, opts ...HedgeOption) *Hedge[T] {
+	cfg := DefaultHedgeConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// Initialize metrics to 0 so they show up in Grafana immediately
+	hedgesSent.WithLabelValues(serviceName).Add(0)
+	hedgesWon.WithLabelValues(serviceName).Add(0)
+
+	return &Hedge[T]{
+		enabled:     cfg.Enabled,
+		delay:       cfg.Delay,
+		percentile:  cfg.Percentile,
+		maxInFlight: cfg.MaxInFlight,
+		latencies:   make([]time.Duration, 0, hedgeLatencySamples),
+		serviceName: serviceName,
+	}
+}
+
+// hedgeResult is the outcome of one of the hedged requests
+type hedgeResult[T any] struct {
+	value T
+	err   error
+	hedge bool
+}
+
+// Execute calls fn and, if it has not answered within the hedge delay, calls it
+// a second time unless MaxInFlight hedges are already running
+// The first success or non-retryable error wins and the other call's context is cancelled;
+// a retryable error only wins once no other call is left
+func (h *Hedge[T]) Execute(ctx context.Context, fn func(ctx context.Context) (T, error)) (T, error) {
+	if !h.enabled {
+		return fn(ctx)
+	}
+
+	// Cancelling on return stops the losing request
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult[T], 2)
+	launch := func(hedge bool) {
+		go func() {
+			if hedge {
+				defer h.release()
+			}
+			value, err := fn(ctx)
+			results <- hedgeResult[T]{value: value, err: err, hedge: hedge}
+		}()
+	}
+
+	start := time.Now()
+	launch(false)
+	timer := time.NewTimer(h.currentDelay())
+	defer timer.Stop()
+
+	pending := 1
+	for {
+		select {
+		case <-timer.C:
+			if h.acquire() {
+				hedgesSent.WithLabelValues(h.serviceName).Inc()
+				launch(true)
+				pending++
+			}
+		case r := <-results:
+			pending--
+			if r.err != nil && DefaultIsRetryable(r.err) && pending > 0 {
+				// The other request may still succeed
+				continue
+			}
+			if r.err == nil {
+				// Record the first request's latency, which the delay is derived from;
+				// if the hedge won, the first is still running and this is a lower bound
+				h.observe(time.Since(start))
+			}
+			if r.hedge {
+				hedgesWon.WithLabelValues(h.serviceName).Inc()
+			}
+			return r.value, r.err
+		}
+	}
+}
+
+// Name returns the service name the hedge reports metrics under
+func (h *Hedge[T]) Name() string {
+	return h.serviceName
+}
+
+// currentDelay returns how long to wait before hedging
+// Once enough latencies are observed it is their configured percentile,
+// before that the configured delay
+func (h *Hedge[T]) currentDelay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.percentile <= 0 || len(h.latencies) < hedgeMinLatencySamples {
+		return h.delay
+	}
+	sorted := slices.Clone(h.latencies)
+	slices.Sort(sorted)
+	i := int(float64(len(sorted)-1) * h.percentile / 100)
+	return sorted[i]
+}
+
+// observe records the latency of a successful first request
+func (h *Hedge[T]) observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.latencies) < hedgeLatencySamples {
+		h.latencies = append(h.latencies, d)
+		return
+	}
+	h.latencies[h.next] = d
+	h.next = (h.next + 1) % hedgeLatencySamples
+}
+
+// acquire takes a hedge slot, reporting false once MaxInFlight hedges are running
+func (h *Hedge[T]) acquire() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.inFlight >= h.maxInFlight {
+		return false
+	}
+	h.inFlight++
+	return true
+}
+
+// release returns a hedge slot
+func (h *Hedge[T]) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.inFlight--
+}
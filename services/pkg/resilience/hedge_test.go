@@ -0,0 +1,155 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHedgeWinsOverSlowRequest(t *testing.T) {
+	const name = "test-hedge-win"
+	h := NewHedge[int](name, WithHedgeDelay(10*time.Millisecond))
+	sent := testutil.ToFloat64(hedgesSent.WithLabelValues(name))
+	won := testutil.ToFloat64(hedgesWon.WithLabelValues(name))
+
+	var calls atomic.Int32
+	cancelled := make(chan struct{})
+	got, err := h.Execute(context.Background(), func(ctx context.Context) (int, error) {
+		if calls.Add(1) == 1 {
+			// The first request hangs until the hedge wins and cancels it
+			<-ctx.Done()
+			close(cancelled)
+			return 0, ctx.Err()
+		}
+		return 2, nil
+	})
+	if err != nil || got != 2 {
+		t.Fatalf("Execute = %d, %v, want hedge result 2", got, err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("losing request was not cancelled")
+	}
+	if got := testutil.ToFloat64(hedgesSent.WithLabelValues(name)) - sent; got != 1 {
+		t.Fatalf("hedged_requests_sent_total = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(hedgesWon.WithLabelValues(name)) - won; got != 1 {
+		t.Fatalf("hedged_requests_won_total = %v, want 1", got)
+	}
+}
+
+func TestHedgeNotSentForFastRequest(t *testing.T) {
+	const name = "test-hedge-fast"
+	h := NewHedge[int](name, WithHedgeDelay(time.Second))
+
+	got, err := h.Execute(context.Background(), func(ctx context.Context) (int, error) { return 1, nil })
+	if err != nil || got != 1 {
+		t.Fatalf("Execute = %d, %v, want 1", got, err)
+	}
+	if got := testutil.ToFloat64(hedgesSent.WithLabelValues(name)); got != 0 {
+		t.Fatalf("hedged_requests_sent_total = %v, want 0", got)
+	}
+}
+
+func TestHedgeWaitsOutRetryableFailure(t *testing.T) {
+	h := NewHedge[int]("test-hedge-failure", WithHedgeDelay(time.Millisecond))
+
+	release := make(chan struct{})
+	var calls atomic.Int32
+	got, err := h.Execute(context.Background(), func(ctx context.Context) (int, error) {
+		if calls.Add(1) == 1 {
+			// Fail only once the hedge is running
+			<-release
+			return 0, errRetryable
+		}
+		close(release)
+		time.Sleep(10 * time.Millisecond)
+		return 2, nil
+	})
+	if err != nil || got != 2 {
+		t.Fatalf("Execute = %d, %v, want hedge result 2", got, err)
+	}
+}
+
+func TestHedgeCap(t *testing.T) {
+	const name = "test-hedge-cap"
+	h := NewHedge[int](name, WithHedgeDelay(time.Millisecond), WithMaxHedgesInFlight(0))
+
+	_, err := h.Execute(context.Background(), func(ctx context.Context) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return 0, errors.New("order not found")
+	})
+	if err == nil {
+		t.Fatal("expected the first request's error")
+	}
+	if got := testutil.ToFloat64(hedgesSent.WithLabelValues(name)); got != 0 {
+		t.Fatalf("hedged_requests_sent_total = %v, want 0 with a cap of 0", got)
+	}
+}
+
+func TestHedgeDelayFromPercentile(t *testing.T) {
+	h := NewHedge[int]("test-hedge-percentile", WithHedgePercentile(95, time.Second))
+
+	if got := h.currentDelay(); got != time.Second {
+		t.Fatalf("delay before samples = %v, want fallback 1s", got)
+	}
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	if got := h.currentDelay(); got != 95*time.Millisecond {
+		t.Fatalf("delay = %v, want p95 of 95ms", got)
+	}
+}
+
+func TestHedgeObservesFirstRequestLatency(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	tests := []struct {
+		name  string
+		first time.Duration // how long the first request takes, 0 for until cancelled
+	}{
+		{"first request wins", 30 * time.Millisecond},
+		{"hedge wins", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHedge[int]("test-hedge-observe", WithHedgeDelay(delay))
+
+			var calls atomic.Int32
+			_, err := h.Execute(context.Background(), func(ctx context.Context) (int, error) {
+				if calls.Add(1) == 1 {
+					if tt.first == 0 {
+						<-ctx.Done()
+						return 0, ctx.Err()
+					}
+					time.Sleep(tt.first)
+					return 1, nil
+				}
+				// The hedge answers at once, or after the first request if that wins
+				if tt.first > 0 {
+					<-ctx.Done()
+					return 0, ctx.Err()
+				}
+				return 2, nil
+			})
+			if err != nil {
+				t.Fatalf("Execute error = %v", err)
+			}
+
+			if len(h.latencies) != 1 {
+				t.Fatalf("latency samples = %d, want 1", len(h.latencies))
+			}
+			want := max(delay, tt.first)
+			if got := h.latencies[0]; got < want {
+				t.Errorf("latency sample = %v, want at least %v of the first request", got, want)
+			}
+		})
+	}
+}
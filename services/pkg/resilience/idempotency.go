@@ -0,0 +1,155 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// IdempotencyKeyHeader is the request header carrying the idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Request outcomes reported by idempotency_requests_total
+const (
+	idempotencyResultNew        = "new"
+	idempotencyResultReplayed   = "replayed"
+	idempotencyResultMismatch   = "mismatch"
+	idempotencyResultInProgress = "in_progress"
+)
+
+var idempotencyRequests = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "idempotency_requests_total",
+		Help: "Total number of requests carrying an idempotency key by outcome (new, replayed, mismatch, in_progress)",
+	},
+	[]string{"store", "result"},
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key arrives again with a different request
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+	// ErrIdempotencyKeyInProgress is returned when a key arrives again before its first request finished
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// IdempotentResponse is the response stored for an idempotency key
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// idempotencyEntry tracks a single key
+// response is nil while the first request is in progress
+type idempotencyEntry struct {
+	fingerprint string
+	response    *IdempotentResponse
+	expiresAt   time.Time
+}
+
+// IdempotencyStore remembers the response to each idempotency key for a TTL,
+// so a repeated request can be answered without being processed again
+type IdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	ttl       time.Duration
+	lastSweep time.Time
+	name      string
+}
+
+// NewIdempotencyStore creates an in-memory store keeping responses for ttl
+func NewIdempotencyStore(name string, ttl time.Duration) *IdempotencyStore {
+	for _, result := range []string{idempotencyResultNew, idempotencyResultReplayed, idempotencyResultMismatch, idempotencyResultInProgress} {
+		idempotencyRequests.WithLabelValues(name, result).Add(0)
+	}
+
+	return &IdempotencyStore{
+		entries:   make(map[string]*idempotencyEntry),
+		ttl:       ttl,
+		lastSweep: time.Now(),
+		name:      name,
+	}
+}
+
+// Begin claims key for a request identified by fingerprint
+// It returns the stored response if the request was already answered, nil if
+// the caller must process the request and then call Complete or Abandon,
+// ErrIdempotencyKeyReused if the fingerprint differs from the first request,
+// or ErrIdempotencyKeyInProgress if the first request has not finished yet
+func (s *IdempotencyStore) Begin(key, fingerprint string) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		s.entries[key] = &idempotencyEntry{fingerprint: fingerprint, expiresAt: now.Add(s.ttl)}
+		idempotencyRequests.WithLabelValues(s.name, idempotencyResultNew).Inc()
+		return nil, nil
+	}
+
+	switch {
+	case entry.fingerprint != fingerprint:
+		idempotencyRequests.WithLabelValues(s.name, idempotencyResultMismatch).Inc()
+		return nil, ErrIdempotencyKeyReused
+	case entry.response == nil:
+		idempotencyRequests.WithLabelValues(s.name, idempotencyResultInProgress).Inc()
+		return nil, ErrIdempotencyKeyInProgress
+	default:
+		idempotencyRequests.WithLabelValues(s.name, idempotencyResultReplayed).Inc()
+		return entry.response, nil
+	}
+}
+
+// Complete stores the response to a request claimed with Begin
+func (s *IdempotencyStore) Complete(key string, response IdempotentResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok {
+		entry.response = &response
+		entry.expiresAt = time.Now().Add(s.ttl)
+	}
+}
+
+// Abandon releases a key claimed with Begin without storing a response,
+// so the request can be retried with the same key
+func (s *IdempotencyStore) Abandon(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// sweep drops expired entries, at most once per TTL
+// Callers hold s.mu
+func (s *IdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// idempotencyKeyContextKey is the context key under which the idempotency key is stored
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context carrying key, for clients to forward downstream
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key carried by ctx, if any
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key, ok && key != ""
+}
@@ -0,0 +1,63 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyStoreReplay(t *testing.T) {
+	s := NewIdempotencyStore("test-replay", time.Hour)
+
+	if stored, err := s.Begin("key", "a"); stored != nil || err != nil {
+		t.Fatalf("first Begin = %v, %v, want nil, nil", stored, err)
+	}
+	if _, err := s.Begin("key", "a"); !errors.Is(err, ErrIdempotencyKeyInProgress) {
+		t.Fatalf("Begin while in progress err = %v, want ErrIdempotencyKeyInProgress", err)
+	}
+
+	s.Complete("key", IdempotentResponse{StatusCode: 200, ContentType: "application/json", Body: []byte(`{"id":1}`)})
+
+	stored, err := s.Begin("key", "a")
+	if err != nil || stored == nil || stored.StatusCode != 200 || string(stored.Body) != `{"id":1}` {
+		t.Fatalf("Begin after Complete = %+v, %v, want stored response", stored, err)
+	}
+	if _, err := s.Begin("key", "b"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("Begin with different fingerprint err = %v, want ErrIdempotencyKeyReused", err)
+	}
+}
+
+func TestIdempotencyStoreAbandon(t *testing.T) {
+	s := NewIdempotencyStore("test-abandon", time.Hour)
+
+	_, _ = s.Begin("key", "a")
+	s.Abandon("key")
+
+	if stored, err := s.Begin("key", "b"); stored != nil || err != nil {
+		t.Fatalf("Begin after Abandon = %v, %v, want nil, nil", stored, err)
+	}
+}
+
+func TestIdempotencyStoreExpiry(t *testing.T) {
+	s := NewIdempotencyStore("test-expiry", 10*time.Millisecond)
+
+	_, _ = s.Begin("key", "a")
+	s.Complete("key", IdempotentResponse{StatusCode: 200})
+	time.Sleep(20 * time.Millisecond)
+
+	if stored, err := s.Begin("key", "b"); stored != nil || err != nil {
+		t.Fatalf("Begin after expiry = %v, %v, want nil, nil", stored, err)
+	}
+}
+
+func TestIdempotencyKeyContext(t *testing.T) {
+	if _, ok := IdempotencyKeyFromContext(context.Background()); ok {
+		t.Fatal("empty context carries a key")
+	}
+
+	ctx := WithIdempotencyKey(context.Background(), "key")
+	if key, ok := IdempotencyKeyFromContext(ctx); !ok || key != "key" {
+		t.Fatalf("IdempotencyKeyFromContext = %q, %v, want key, true", key, ok)
+	}
+}
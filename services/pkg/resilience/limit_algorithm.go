@@ -0,0 +1,134 @@
+package resilience
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Limit algorithms supported by AdaptiveLimiter
+const (
+	LimitAlgorithmAIMD     = "aimd"
+	LimitAlgorithmVegas    = "vegas"
+	LimitAlgorithmGradient = "gradient"
+)
+
+// limitSample describes one completed call seen by an AdaptiveLimiter
+type limitSample struct {
+	// rtt is how long the call took
+	rtt time.Duration
+	// inFlight is the number of calls in flight when the call started, itself included
+	inFlight int
+	// dropped reports whether the call failed in a way that signals overload
+	dropped bool
+}
+
+// limitAlgorithm computes a new concurrency limit from a completed call
+// Implementations are not safe for concurrent use; the owning limiter serializes calls
+type limitAlgorithm interface {
+	update(limit float64, sample limitSample) float64
+}
+
+// newLimitAlgorithm returns the algorithm registered under name
+func newLimitAlgorithm(name string) (limitAlgorithm, error) {
+	switch name {
+	case LimitAlgorithmAIMD:
+		return &aimdLimit{backoffRatio: 0.9}, nil
+	case LimitAlgorithmVegas:
+		return &vegasLimit{}, nil
+	case LimitAlgorithmGradient:
+		return &gradientLimit{smoothing: 0.2, longWindow: 600}, nil
+	default:
+		return nil, fmt.Errorf("unknown limit algorithm %q", name)
+	}
+}
+
+// appLimited reports whether the caller used too little of the limit for a
+// sample to justify raising it
+func appLimited(limit float64, sample limitSample) bool {
+	return float64(sample.inFlight)*2 < limit
+}
+
+// aimdLimit grows the limit by one after each successful call and cuts it by
+// backoffRatio after each dropped call (additive increase, multiplicative decrease)
+type aimdLimit struct {
+	backoffRatio float64
+}
+
+func (a *aimdLimit) update(limit float64, sample limitSample) float64 {
+	if sample.dropped {
+		return limit * a.backoffRatio
+	}
+	if appLimited(limit, sample) {
+		return limit
+	}
+	return limit + 1
+}
+
+// vegasLimit estimates the queue building up at the service from how far the
+// latency has risen above the lowest latency seen, and keeps that queue small
+// Like TCP Vegas it grows quickly while the queue is empty and backs off once it builds
+type vegasLimit struct {
+	rttNoLoad time.Duration
+}
+
+func (v *vegasLimit) update(limit float64, sample limitSample) float64 {
+	if v.rttNoLoad == 0 || sample.rtt < v.rttNoLoad {
+		v.rttNoLoad = sample.rtt
+		return limit
+	}
+
+	step := math.Max(1, math.Log10(limit))
+	if sample.dropped {
+		return limit - step
+	}
+	if appLimited(limit, sample) {
+		return limit
+	}
+
+	queue := math.Ceil(limit * (1 - float64(v.rttNoLoad)/float64(sample.rtt)))
+	alpha, beta := 3*step, 6*step
+	switch {
+	case queue <= step:
+		return limit + beta
+	case queue < alpha:
+		return limit + step
+	case queue > beta:
+		return limit - step
+	default:
+		return limit
+	}
+}
+
+// gradientLimit compares the latency of each call with a long-term average
+// and scales the limit by their ratio, leaving headroom of sqrt(limit) for bursts
+// The change is smoothed so a single slow call cannot collapse the limit
+type gradientLimit struct {
+	smoothing  float64
+	longWindow int
+	longRTT    float64
+}
+
+func (g *gradientLimit) update(limit float64, sample limitSample) float64 {
+	rtt := float64(sample.rtt)
+	if rtt <= 0 {
+		return limit
+	}
+	if g.longRTT == 0 {
+		g.longRTT = rtt
+	} else {
+		alpha := 2 / float64(g.longWindow+1)
+		g.longRTT = g.longRTT*(1-alpha) + rtt*alpha
+	}
+
+	if !sample.dropped && appLimited(limit, sample) {
+		return limit
+	}
+
+	gradient := 0.5
+	if !sample.dropped {
+		gradient = math.Max(0.5, math.Min(1, g.longRTT/rtt))
+	}
+	target := limit*gradient + math.Sqrt(limit)
+	return limit*(1-g.smoothing) + target*g.smoothing
+}
@@ -0,0 +1,319 @@
+package resilience
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Load shedding results reported in the load_shedder_requests_total metric
+const (
+	loadShedResultAdmitted = "admitted"
+	loadShedResultShed     = "shed"
+)
+
+var (
+	loadShedderRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "load_shedder_requests_total",
+			Help: "Total number of requests checked by a load shedder by priority and result (admitted, shed)",
+		},
+		[]string{"shedder", "priority", "result"},
+	)
+
+	loadShedderQueueSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "load_shedder_queue_seconds",
+			Help:    "Time requests spent queued by a load shedder",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		},
+		[]string{"shedder"},
+	)
+
+	loadShedderOverloaded = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "load_shedder_overloaded",
+			Help: "Whether a load shedder considers its service overloaded (1) or not (0)",
+		},
+		[]string{"shedder"},
+	)
+)
+
+// PriorityHeader carries the priority of a request, set by the gateway
+const PriorityHeader = "X-Priority"
+
+// Priority ranks requests for load shedding; lower values are shed last
+type Priority int
+
+const (
+	PriorityCritical Priority = iota
+	PriorityNormal
+	PrioritySheddable
+)
+
+// priorities lists every priority, most important first
+var priorities = []Priority{PriorityCritical, PriorityNormal, PrioritySheddable}
+
+// ParsePriority reads a priority header value: critical, normal or sheddable
+// Missing or unknown values are treated as normal
+func ParsePriority(s string) Priority {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "critical":
+		return PriorityCritical
+	case "sheddable":
+		return PrioritySheddable
+	default:
+		return PriorityNormal
+	}
+}
+
+// String returns the header value of the priority
+func (p Priority) String() string {
+	switch p {
+	case PriorityCritical:
+		return "critical"
+	case PrioritySheddable:
+		return "sheddable"
+	default:
+		return "normal"
+	}
+}
+
+// ErrLoadShed is returned when a request is dropped to keep the service responsive
+var ErrLoadShed = errors.New("request shed under load")
+
+// shedWaiter is a request queued by a load shedder
+type shedWaiter struct {
+	priority Priority
+	queuedAt time.Time
+	done     chan struct{} // closed once err is decided
+	err      error         // nil when admitted, ErrLoadShed when evicted
+}
+
+// LoadShedder admits requests while the service keeps up and sheds them when it doesn't
+// Requests beyond MaxInFlight queue by priority; CoDel watches how long they wait
+// Once queueing latency stays above Target for a whole Interval the service is
+// overloaded: sheddable requests are rejected outright and normal ones wait at most
+// Target, while critical requests keep waiting up to Interval
+// When the queue is full the least important waiter makes room for a more important request
+type LoadShedder struct {
+	mu               sync.Mutex
+	cfg              LoadShedderConfig
+	inFlight         func() int
+	waiters          map[Priority]*list.List // of *shedWaiter, oldest first
+	queued           int
+	firstAboveTarget time.Time
+	overloaded       bool
+	name             string
+}
+
+// NewLoadShedder creates a load shedder
+// inFlight reports the requests currently in the service, including queued ones and
+// the one being admitted, e.g. a count kept by the middleware that calls Admit
+func NewLoadShedder(name string, cfg LoadShedderConfig, inFlight func() int) *LoadShedder {
+	s := &LoadShedder{
+		cfg:      cfg,
+		inFlight: inFlight,
+		waiters:  make(map[Priority]*list.List, len(priorities)),
+		name:     name,
+	}
+	for _, p := range priorities {
+		s.waiters[p] = list.New()
+		loadShedderRequests.WithLabelValues(name, p.String(), loadShedResultAdmitted).Add(0)
+		loadShedderRequests.WithLabelValues(name, p.String(), loadShedResultShed).Add(0)
+	}
+
+	// Initialize metrics to 0 so they show up in Grafana immediately
+	loadShedderOverloaded.WithLabelValues(name).Set(0)
+
+	return s
+}
+
+// Admit decides whether a request of the given priority may proceed, queueing it if needed
+// On success the returned release func must be called once the request is done
+// Returns ErrLoadShed if the request is shed, or the context error if ctx is done first
+func (s *LoadShedder) Admit(ctx context.Context, priority Priority) (func(), error) {
+	err := s.admit(ctx, priority)
+	switch {
+	case err == nil:
+		loadShedderRequests.WithLabelValues(s.name, priority.String(), loadShedResultAdmitted).Inc()
+		return s.release, nil
+	case errors.Is(err, ErrLoadShed):
+		loadShedderRequests.WithLabelValues(s.name, priority.String(), loadShedResultShed).Inc()
+	}
+	return nil, err
+}
+
+// Overloaded reports whether queueing latency has stayed above Target for an Interval
+func (s *LoadShedder) Overloaded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.overloaded
+}
+
+// admit runs the admission decision of Admit
+func (s *LoadShedder) admit(ctx context.Context, priority Priority) error {
+	start := time.Now()
+
+	s.mu.Lock()
+	if s.queued == 0 && s.executing() <= s.cfg.MaxInFlight {
+		// An empty queue means the service keeps up
+		s.observe(0, start)
+		s.mu.Unlock()
+		loadShedderQueueSeconds.WithLabelValues(s.name).Observe(0)
+		return nil
+	}
+	if s.overloaded && priority == PrioritySheddable {
+		s.mu.Unlock()
+		return ErrLoadShed
+	}
+	if s.queued >= s.cfg.MaxQueue && !s.evictBelow(priority) {
+		s.mu.Unlock()
+		return ErrLoadShed
+	}
+
+	w := &shedWaiter{priority: priority, queuedAt: start, done: make(chan struct{})}
+	elem := s.waiters[priority].PushBack(w)
+	s.queued++
+	maxWait := s.cfg.Interval
+	if s.overloaded && priority != PriorityCritical {
+		maxWait = s.cfg.Target
+	}
+	s.mu.Unlock()
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	var err error
+	timedOut := false
+	select {
+	case <-w.done:
+		loadShedderQueueSeconds.WithLabelValues(s.name).Observe(time.Since(start).Seconds())
+		return w.err
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+		err = ErrLoadShed
+		timedOut = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Capacity may have freed up without a release, e.g. from requests that bypass the shedder
+	s.dispatch()
+	select {
+	case <-w.done:
+		if w.err == nil && timedOut {
+			// Admitted just as the wait ran out
+			loadShedderQueueSeconds.WithLabelValues(s.name).Observe(time.Since(start).Seconds())
+			return nil
+		}
+		if w.err == nil {
+			// Admitted while giving up; pass the slot on
+			s.handOff()
+		}
+		return err
+	default:
+	}
+	s.waiters[priority].Remove(elem)
+	s.queued--
+	wait := time.Since(start)
+	s.observe(wait, time.Now())
+	loadShedderQueueSeconds.WithLabelValues(s.name).Observe(wait.Seconds())
+	return err
+}
+
+// release ends an admitted request, handing its slot to the most important waiter
+func (s *LoadShedder) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handOff()
+	s.dispatch()
+}
+
+// handOff admits the most important waiter in place of a finishing request
+// Callers hold s.mu
+func (s *LoadShedder) handOff() {
+	for _, p := range priorities {
+		if front := s.waiters[p].Front(); front != nil {
+			s.grant(front, p)
+			return
+		}
+	}
+}
+
+// dispatch admits waiters while the service has spare capacity
+// Callers hold s.mu
+func (s *LoadShedder) dispatch() {
+	for s.queued > 0 && s.executing() < s.cfg.MaxInFlight {
+		s.handOff()
+	}
+}
+
+// grant admits a queued request and feeds its queueing latency to CoDel
+// Callers hold s.mu
+func (s *LoadShedder) grant(elem *list.Element, p Priority) {
+	w := s.waiters[p].Remove(elem).(*shedWaiter)
+	s.queued--
+	close(w.done)
+	now := time.Now()
+	s.observe(now.Sub(w.queuedAt), now)
+}
+
+// evictBelow sheds the newest waiter less important than priority to make room
+// Returns false if every waiter is at least as important
+// Callers hold s.mu
+func (s *LoadShedder) evictBelow(priority Priority) bool {
+	for i := len(priorities) - 1; priorities[i] > priority; i-- {
+		if back := s.waiters[priorities[i]].Back(); back != nil {
+			w := s.waiters[priorities[i]].Remove(back).(*shedWaiter)
+			s.queued--
+			w.err = ErrLoadShed
+			close(w.done)
+			return true
+		}
+	}
+	return false
+}
+
+// executing estimates the requests being served, including the one being admitted
+// Callers hold s.mu
+func (s *LoadShedder) executing() int {
+	return s.inFlight() - s.queued
+}
+
+// observe feeds a queueing latency to CoDel
+// Latency below Target clears the overload, latency above Target for a whole
+// Interval sets it
+// Callers hold s.mu
+func (s *LoadShedder) observe(sojourn time.Duration, now time.Time) {
+	switch {
+	case sojourn < s.cfg.Target:
+		s.firstAboveTarget = time.Time{}
+		s.setOverloaded(false)
+	case s.firstAboveTarget.IsZero():
+		s.firstAboveTarget = now.Add(s.cfg.Interval)
+	case !now.Before(s.firstAboveTarget):
+		s.setOverloaded(true)
+	}
+}
+
+// setOverloaded records the overload state
+// Callers hold s.mu
+func (s *LoadShedder) setOverloaded(overloaded bool) {
+	if s.overloaded == overloaded {
+		return
+	}
+	s.overloaded = overloaded
+	value := 0.0
+	if overloaded {
+		value = 1
+	}
+	loadShedderOverloaded.WithLabelValues(s.name).Set(value)
+}
@@ -0,0 +1,181 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// requestCounter stands in for the in-flight gauge of a service
+type requestCounter struct {
+	n atomic.Int32
+}
+
+func (c *requestCounter) value() int {
+	return int(c.n.Load())
+}
+
+// admit counts a request in flight for as long as it is queued or admitted
+func (c *requestCounter) admit(s *LoadShedder, p Priority) (func(), error) {
+	c.n.Add(1)
+	release, err := s.Admit(context.Background(), p)
+	if err != nil {
+		c.n.Add(-1)
+		return nil, err
+	}
+	return func() {
+		release()
+		c.n.Add(-1)
+	}, nil
+}
+
+// queuedCount returns the number of waiting requests
+func queuedCount(s *LoadShedder) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queued
+}
+
+// waitQueued blocks until n requests are waiting
+func waitQueued(t *testing.T, s *LoadShedder, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for queuedCount(s) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("queued = %d, want %d", queuedCount(s), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := map[string]Priority{
+		"critical":   PriorityCritical,
+		" Sheddable": PrioritySheddable,
+		"normal":     PriorityNormal,
+		"":           PriorityNormal,
+		"urgent":     PriorityNormal,
+	}
+	for header, want := range tests {
+		if got := ParsePriority(header); got != want {
+			t.Errorf("ParsePriority(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestLoadShedderAdmitsCriticalFirst(t *testing.T) {
+	var counter requestCounter
+	s := NewLoadShedder("test-priority", LoadShedderConfig{MaxInFlight: 1, MaxQueue: 10, Target: time.Second, Interval: time.Second}, counter.value)
+
+	occupant, err := counter.admit(s, PriorityNormal)
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+
+	admitted := make(chan Priority, 2)
+	for i, p := range []Priority{PrioritySheddable, PriorityCritical} {
+		go func() {
+			release, err := counter.admit(s, p)
+			if err != nil {
+				t.Errorf("%v request: %v", p, err)
+				return
+			}
+			admitted <- p
+			release()
+		}()
+		waitQueued(t, s, i+1)
+	}
+
+	occupant()
+	if first := <-admitted; first != PriorityCritical {
+		t.Fatalf("first admitted = %v, want critical", first)
+	}
+	if second := <-admitted; second != PrioritySheddable {
+		t.Fatalf("second admitted = %v, want sheddable", second)
+	}
+}
+
+func TestLoadShedderEvictsLowerPriorityWhenFull(t *testing.T) {
+	var counter requestCounter
+	s := NewLoadShedder("test-evict", LoadShedderConfig{MaxInFlight: 1, MaxQueue: 1, Target: time.Second, Interval: time.Second}, counter.value)
+	shed := testutil.ToFloat64(loadShedderRequests.WithLabelValues("test-evict", "sheddable", loadShedResultShed))
+
+	occupant, _ := counter.admit(s, PriorityNormal)
+
+	evicted := make(chan error, 1)
+	go func() {
+		_, err := counter.admit(s, PrioritySheddable)
+		evicted <- err
+	}()
+	waitQueued(t, s, 1)
+
+	// A request no more important than the waiter is shed itself
+	if _, err := counter.admit(s, PrioritySheddable); !errors.Is(err, ErrLoadShed) {
+		t.Fatalf("second sheddable request = %v, want ErrLoadShed", err)
+	}
+
+	admitted := make(chan error, 1)
+	go func() {
+		release, err := counter.admit(s, PriorityCritical)
+		if err == nil {
+			release()
+		}
+		admitted <- err
+	}()
+
+	if err := <-evicted; !errors.Is(err, ErrLoadShed) {
+		t.Fatalf("queued sheddable request = %v, want ErrLoadShed", err)
+	}
+	waitQueued(t, s, 1)
+	occupant()
+	if err := <-admitted; err != nil {
+		t.Fatalf("critical request: %v", err)
+	}
+
+	if got := testutil.ToFloat64(loadShedderRequests.WithLabelValues("test-evict", "sheddable", loadShedResultShed)) - shed; got != 2 {
+		t.Fatalf("shed sheddable requests = %v, want 2", got)
+	}
+}
+
+func TestLoadShedderShedsSheddableWhenOverloaded(t *testing.T) {
+	var counter requestCounter
+	s := NewLoadShedder("test-codel", LoadShedderConfig{MaxInFlight: 1, MaxQueue: 10, Target: time.Millisecond, Interval: 10 * time.Millisecond}, counter.value)
+
+	occupant, _ := counter.admit(s, PriorityNormal)
+
+	// Queueing latency above target for a whole interval marks the service overloaded
+	for i := 0; i < 2; i++ {
+		if _, err := counter.admit(s, PriorityNormal); !errors.Is(err, ErrLoadShed) {
+			t.Fatalf("queued request %d = %v, want ErrLoadShed", i+1, err)
+		}
+	}
+	if !s.Overloaded() {
+		t.Fatal("shedder is not overloaded")
+	}
+	if got := testutil.ToFloat64(loadShedderOverloaded.WithLabelValues("test-codel")); got != 1 {
+		t.Fatalf("overloaded gauge = %v, want 1", got)
+	}
+
+	start := time.Now()
+	if _, err := counter.admit(s, PrioritySheddable); !errors.Is(err, ErrLoadShed) {
+		t.Fatalf("sheddable request = %v, want ErrLoadShed", err)
+	}
+	if waited := time.Since(start); waited > 5*time.Millisecond {
+		t.Fatalf("sheddable request waited %v, want immediate rejection", waited)
+	}
+
+	// An empty queue clears the overload
+	occupant()
+	release, err := counter.admit(s, PrioritySheddable)
+	if err != nil {
+		t.Fatalf("request after recovery: %v", err)
+	}
+	release()
+	if s.Overloaded() {
+		t.Fatal("shedder is still overloaded")
+	}
+}
@@ -0,0 +1,45 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCircuitBreakerMetrics(t *testing.T) {
+	const name = "test-metrics"
+	cb := NewCircuitBreaker[int](name, WithFailureThreshold(1), WithOpenTimeout(time.Minute))
+
+	if got := testutil.ToFloat64(circuitBreakerState.WithLabelValues(name, "closed")); got != 1 {
+		t.Fatalf(`circuit_breaker_state{state="closed"} = %v, want 1`, got)
+	}
+
+	// Counters outlive the breaker, so compare against their values before the calls
+	opened := testutil.ToFloat64(circuitBreakerTransitions.WithLabelValues(name, "closed", "open"))
+	failures := testutil.ToFloat64(circuitBreakerCalls.WithLabelValues(name, callResultFailure))
+	rejected := testutil.ToFloat64(circuitBreakerCalls.WithLabelValues(name, callResultRejected))
+	counted := testutil.ToFloat64(circuitBreakerFailures.WithLabelValues(name))
+
+	failN(cb, 1)
+	_, _ = cb.Execute(func() (int, error) { return 1, nil })
+
+	states := map[string]float64{"closed": 0, "open": 1, "half-open": 0}
+	for state, want := range states {
+		if got := testutil.ToFloat64(circuitBreakerState.WithLabelValues(name, state)); got != want {
+			t.Fatalf("circuit_breaker_state{state=%q} = %v, want %v", state, got, want)
+		}
+	}
+	if got := testutil.ToFloat64(circuitBreakerTransitions.WithLabelValues(name, "closed", "open")) - opened; got != 1 {
+		t.Fatalf("closed->open transitions = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(circuitBreakerCalls.WithLabelValues(name, callResultFailure)) - failures; got != 1 {
+		t.Fatalf("failure calls = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(circuitBreakerCalls.WithLabelValues(name, callResultRejected)) - rejected; got != 1 {
+		t.Fatalf("rejected calls = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(circuitBreakerFailures.WithLabelValues(name)) - counted; got != 1 {
+		t.Fatalf("circuit_breaker_failures_total = %v, want 1 (rejections excluded)", got)
+	}
+}
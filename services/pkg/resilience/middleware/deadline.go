@@ -0,0 +1,42 @@
+//go:build !stage
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+// DeadlineMiddleware bounds each request by the deadline its caller propagated
+// in the X-Request-Deadline header, or by cfg.Timeout when there is none
+// Requests whose remaining budget is below cfg.Floor are rejected before any work starts
+// Resilient version: Clients forward the remaining budget downstream
+func DeadlineMiddleware(cfg resilience.DeadlineConfig, renderError ErrorRenderer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budget, ok, err := resilience.RequestBudget(c.GetHeader(resilience.DeadlineHeader), cfg)
+		if errors.Is(err, resilience.ErrDeadlineTooShort) {
+			renderError(c, http.StatusGatewayTimeout, fmt.Sprintf("Request deadline budget too short: %v", err))
+			return
+		}
+		if err != nil {
+			renderError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !ok {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), budget)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
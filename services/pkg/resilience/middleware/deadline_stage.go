@@ -0,0 +1,17 @@
+//go:build stage
+
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+// DeadlineMiddleware leaves requests unbounded
+// Stage version: No deadline propagation - allows full cascade failure
+func DeadlineMiddleware(cfg resilience.DeadlineConfig, renderError ErrorRenderer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+	}
+}
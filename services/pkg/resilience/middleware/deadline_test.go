@@ -0,0 +1,56 @@
+//go:build !stage
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+func TestDeadlineMiddleware(t *testing.T) {
+	cfg := resilience.DeadlineConfig{Timeout: 5 * time.Second, Floor: 50 * time.Millisecond}
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantBudget time.Duration // upper bound of the deadline seen by the handler
+	}{
+		{"no header uses timeout", "", http.StatusOK, 5 * time.Second},
+		{"header shortens timeout", "1000", http.StatusOK, time.Second},
+		{"budget below floor", "10", http.StatusGatewayTimeout, 0},
+		{"invalid header", "soon", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var budget time.Duration
+			router := gin.New()
+			router.GET("/", DeadlineMiddleware(cfg, renderStatus), func(c *gin.Context) {
+				if deadline, ok := c.Request.Context().Deadline(); ok {
+					budget = time.Until(deadline)
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(resilience.DeadlineHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && (budget <= 0 || budget > tt.wantBudget) {
+				t.Fatalf("handler budget = %v, want in (0, %v]", budget, tt.wantBudget)
+			}
+		})
+	}
+}
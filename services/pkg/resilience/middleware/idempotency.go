@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+// maxIdempotencyKeyLength bounds the keys accepted from clients
+const maxIdempotencyKeyLength = 255
+
+// statusClientClosedRequest is the status services record for requests whose caller went away
+const statusClientClosedRequest = 499
+
+// discardResponseKey marks a request whose response must not be stored
+const discardResponseKey = "resilience.idempotency.discard"
+
+// responseRecorder captures the response body while writing it through
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	hijacked bool
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// Hijack notes that the connection was taken over, leaving no response to store
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	return r.ResponseWriter.Hijack()
+}
+
+// IdempotencyMiddleware makes requests carrying an Idempotency-Key header safe to retry
+// The first response to a key is stored and replayed for repeats of the same request,
+// a repeat with a different body is rejected with 409
+// Server errors, 429, dropped connections, requests whose caller went away and responses
+// marked with DiscardResponse are not stored so the request can be retried with the same key
+// The key is added to the request context for clients to forward downstream
+func IdempotencyMiddleware(store *resilience.IdempotencyStore, renderError ErrorRenderer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(resilience.IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			renderError(c, http.StatusBadRequest, fmt.Sprintf("Idempotency-Key must not exceed %d characters", maxIdempotencyKeyLength))
+			return
+		}
+
+		// Fingerprint the body so a reused key with a different request is detected
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			renderError(c, http.StatusBadRequest, fmt.Sprintf("Failed to read request body: %v", err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+
+		stored, err := store.Begin(key, hex.EncodeToString(sum[:]))
+		if err != nil {
+			detail := "Idempotency-Key was already used with a different request body"
+			if errors.Is(err, resilience.ErrIdempotencyKeyInProgress) {
+				detail = "A request with this Idempotency-Key is still being processed"
+			}
+			renderError(c, http.StatusConflict, detail)
+			return
+		}
+		if stored != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(resilience.WithIdempotencyKey(c.Request.Context(), key))
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// Release the key unless a response is stored, even if a handler panics
+		completed := false
+		defer func() {
+			if !completed {
+				store.Abandon(key)
+			}
+		}()
+
+		c.Next()
+
+		if !storable(c, recorder) {
+			return
+		}
+		store.Complete(key, resilience.IdempotentResponse{
+			StatusCode:  recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		})
+		completed = true
+	}
+}
+
+// DiscardResponse keeps the response to the current request from being stored under its
+// Idempotency-Key, so a retry with the key runs the request again
+// Use it for responses that do not reflect the outcome of the request, such as injected faults
+func DiscardResponse(c *gin.Context) {
+	c.Set(discardResponseKey, true)
+}
+
+// storable reports whether the response to a request is final, so repeats may replay it
+// A request cancelled by its caller was cut short, whatever status it ended with
+func storable(c *gin.Context, recorder *responseRecorder) bool {
+	status := recorder.Status()
+	switch {
+	case recorder.hijacked, c.Request.Context().Err() != nil, c.GetBool(discardResponseKey):
+		return false
+	case status >= http.StatusInternalServerError, status == http.StatusTooManyRequests, status == statusClientClosedRequest:
+		return false
+	}
+	return true
+}
+
+// IdempotencyKeyMiddleware gives requests that arrive without an Idempotency-Key one
+// of their own, so every retry of a downstream call made for the request carries
+// the same key and the downstream service processes it only once
+// Minted keys are only added to the request context; place it after IdempotencyMiddleware
+func IdempotencyKeyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := resilience.IdempotencyKeyFromContext(c.Request.Context()); !ok {
+			c.Request = c.Request.WithContext(resilience.WithIdempotencyKey(c.Request.Context(), rand.Text()))
+		}
+		c.Next()
+	}
+}
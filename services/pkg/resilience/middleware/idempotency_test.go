@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/LuoZihYuan/go-down/services/pkg/resilience"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// renderStatus is an ErrorRenderer writing the status and detail as plain text
+func renderStatus(c *gin.Context, status int, detail string) {
+	c.String(status, detail)
+	c.Abort()
+}
+
+// countingRouter serves POST /orders behind the idempotency middleware,
+// answering with status and counting the calls that reach the handler
+func countingRouter(store *resilience.IdempotencyStore, status *int, calls *int) *gin.Engine {
+	router := gin.New()
+	router.POST("/orders", IdempotencyMiddleware(store, renderStatus), func(c *gin.Context) {
+		*calls++
+		key, _ := resilience.IdempotencyKeyFromContext(c.Request.Context())
+		c.JSON(*status, gin.H{"key": key})
+	})
+	return router
+}
+
+func post(router http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(resilience.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddlewareReplaysResponse(t *testing.T) {
+	status, calls := http.StatusOK, 0
+	router := countingRouter(resilience.NewIdempotencyStore("test-mw-replay", time.Minute), &status, &calls)
+
+	first := post(router, "k1", `{"amount":1}`)
+	second := post(router, "k1", `{"amount":1}`)
+	if calls != 1 {
+		t.Fatalf("handler calls = %d, want 1", calls)
+	}
+	if second.Code != http.StatusOK || second.Body.String() != first.Body.String() || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay = %d %q, want the first response replayed", second.Code, second.Body.String())
+	}
+	if !strings.Contains(first.Body.String(), `"k1"`) {
+		t.Fatalf("handler saw body %q, want the key in its context", first.Body.String())
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsReusedKey(t *testing.T) {
+	status, calls := http.StatusOK, 0
+	router := countingRouter(resilience.NewIdempotencyStore("test-mw-reuse", time.Minute), &status, &calls)
+
+	post(router, "k1", `{"amount":1}`)
+	if w := post(router, "k1", `{"amount":2}`); w.Code != http.StatusConflict {
+		t.Fatalf("reused key = %d, want 409", w.Code)
+	}
+	if w := post(router, strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("long key = %d, want 400", w.Code)
+	}
+}
+
+func TestIdempotencyMiddlewareSkipsRetryableResponses(t *testing.T) {
+	for _, code := range []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, statusClientClosedRequest} {
+		status, calls := code, 0
+		router := countingRouter(resilience.NewIdempotencyStore("test-mw-retryable", time.Minute), &status, &calls)
+
+		post(router, "k1", `{}`)
+		status = http.StatusOK
+		if w := post(router, "k1", `{}`); w.Code != http.StatusOK || calls != 2 {
+			t.Fatalf("retry after %d = %d after %d calls, want 200 after 2", code, w.Code, calls)
+		}
+	}
+}
+
+func TestIdempotencyMiddlewareSkipsCancelledRequests(t *testing.T) {
+	status, calls := http.StatusOK, 0
+	router := countingRouter(resilience.NewIdempotencyStore("test-mw-cancelled", time.Minute), &status, &calls)
+
+	// The caller is gone by the time the handler answers
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/orders", strings.NewReader(`{}`))
+	req.Header.Set(resilience.IdempotencyKeyHeader, "k1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if w := post(router, "k1", `{}`); w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "" || calls != 2 {
+		t.Fatalf("retry after cancellation = %d after %d calls, want a new 200 after 2", w.Code, calls)
+	}
+}
+
+func TestIdempotencyMiddlewareSkipsDiscardedResponses(t *testing.T) {
+	calls := 0
+	router := gin.New()
+	router.POST("/orders", IdempotencyMiddleware(resilience.NewIdempotencyStore("test-mw-discard", time.Minute), renderStatus), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			DiscardResponse(c)
+		}
+		c.String(http.StatusOK, "call %d", calls)
+	})
+
+	post(router, "k1", `{}`)
+	if w := post(router, "k1", `{}`); w.Body.String() != "call 2" {
+		t.Fatalf("retry after a discarded response = %q, want %q", w.Body.String(), "call 2")
+	}
+	if w := post(router, "k1", `{}`); w.Body.String() != "call 2" || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("repeat = %q, want the second response replayed", w.Body.String())
+	}
+}
+
+func TestIdempotencyMiddlewarePassesRequestsWithoutKey(t *testing.T) {
+	status, calls := http.StatusOK, 0
+	router := countingRouter(resilience.NewIdempotencyStore("test-mw-no-key", time.Minute), &status, &calls)
+
+	post(router, "", `{}`)
+	post(router, "", `{}`)
+	if calls != 2 {
+		t.Fatalf("handler calls = %d, want 2", calls)
+	}
+}
+
+func TestIdempotencyKeyMiddlewareMintsMissingKeys(t *testing.T) {
+	router := gin.New()
+	router.POST("/orders", IdempotencyMiddleware(resilience.NewIdempotencyStore("test-mw-mint", time.Minute), renderStatus), IdempotencyKeyMiddleware(), func(c *gin.Context) {
+		key, _ := resilience.IdempotencyKeyFromContext(c.Request.Context())
+		c.String(http.StatusOK, key)
+	})
+
+	minted, other := post(router, "", `{}`).Body.String(), post(router, "", `{}`).Body.String()
+	if minted == "" || minted == other {
+		t.Fatalf("minted keys = %q, %q; want distinct keys", minted, other)
+	}
+	if got := post(router, "client-key", `{}`).Body.String(); got != "client-key" {
+		t.Fatalf("key = %q, want the client's key kept", got)
+	}
+}
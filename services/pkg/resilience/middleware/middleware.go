@@ -0,0 +1,9 @@
+// Package middleware provides the gin middleware shared by the go-down services
+// to apply resilience policies to incoming requests
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// ErrorRenderer answers a request a middleware rejects, in the error format of the service
+// Implementations write status with detail as the reason and abort the request
+type ErrorRenderer func(c *gin.Context, status int, detail string)
@@ -0,0 +1,700 @@
+package resilience
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CircuitBreakerConfig holds the tunable settings of a circuit breaker
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of failures in the window that opens the circuit
+	FailureThreshold int
+	// FailureRateThreshold is the percentage of failed calls in the window that opens the circuit
+	// Zero disables rate based tripping in favour of FailureThreshold
+	FailureRateThreshold float64
+	// SlowCallDuration is the call duration above which a call counts as slow
+	// Zero disables slow call detection
+	SlowCallDuration time.Duration
+	// SlowCallRateThreshold is the percentage of slow calls in the window that opens the circuit
+	// Zero keeps slow calls from opening a closed circuit
+	SlowCallRateThreshold float64
+	// FailureWindow is the length of the sliding window failures are counted in
+	FailureWindow time.Duration
+	// SuccessThreshold is the number of successes in half-open needed to close the circuit
+	SuccessThreshold int
+	// HalfOpenMaxProbes is the number of trial requests allowed through at once in half-open
+	HalfOpenMaxProbes int
+	// OpenTimeout is how long the circuit stays open before moving to half-open
+	OpenTimeout time.Duration
+	// MinimumRequests is the number of calls in the window required before the circuit may open
+	MinimumRequests int
+}
+
+// DefaultCircuitBreakerConfig returns the settings used when no options are given
+// 5 failures in 10 seconds opens the circuit for 30 seconds, one successful probe closes it again
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold:      5,
+		FailureRateThreshold:  0,
+		SlowCallDuration:      0,
+		SlowCallRateThreshold: 0,
+		FailureWindow:         10 * time.Second,
+		SuccessThreshold:      1,
+		HalfOpenMaxProbes:     1,
+		OpenTimeout:           30 * time.Second,
+		MinimumRequests:       0,
+	}
+}
+
+// Validate reports whether the configuration can be used to build a circuit breaker
+func (c CircuitBreakerConfig) Validate() error {
+	switch {
+	case c.FailureThreshold < 1:
+		return errors.New("failure threshold must be at least 1")
+	case c.FailureRateThreshold < 0 || c.FailureRateThreshold > 100:
+		return errors.New("failure rate threshold must be between 0 and 100")
+	case c.SlowCallDuration < 0:
+		return errors.New("slow call duration must not be negative")
+	case c.SlowCallRateThreshold < 0 || c.SlowCallRateThreshold > 100:
+		return errors.New("slow call rate threshold must be between 0 and 100")
+	case c.FailureWindow <= 0:
+		return errors.New("failure window must be positive")
+	case c.SuccessThreshold < 1:
+		return errors.New("success threshold must be at least 1")
+	case c.HalfOpenMaxProbes < 1:
+		return errors.New("half-open max probes must be at least 1")
+	case c.OpenTimeout <= 0:
+		return errors.New("open timeout must be positive")
+	case c.MinimumRequests < 0:
+		return errors.New("minimum requests must not be negative")
+	}
+	return nil
+}
+
+// circuitBreakerOptions collects everything the options of a circuit breaker can set
+type circuitBreakerOptions struct {
+	config           CircuitBreakerConfig
+	isFailure        func(error) bool
+	eventHistorySize int
+}
+
+// CircuitBreakerOption configures a circuit breaker
+type CircuitBreakerOption func(*circuitBreakerOptions)
+
+// WithConfig replaces all settings with the given configuration
+// Options applied after it still take effect
+func WithConfig(cfg CircuitBreakerConfig) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.config = cfg
+	}
+}
+
+// WithFailureThreshold sets the number of failures in the window that opens the circuit
+func WithFailureThreshold(n int) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.config.FailureThreshold = n
+	}
+}
+
+// WithFailureRateThreshold switches the circuit to rate based tripping
+// The circuit opens once pct percent of the calls in the window have failed,
+// provided at least MinimumRequests calls were observed
+func WithFailureRateThreshold(pct float64) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.config.FailureRateThreshold = pct
+	}
+}
+
+// WithSlowCallThreshold enables slow call detection
+// Calls taking at least d count as slow, and the circuit opens once pct percent
+// of the calls in the window were slow; a slow half-open probe reopens the circuit
+func WithSlowCallThreshold(d time.Duration, pct float64) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.config.SlowCallDuration = d
+		o.config.SlowCallRateThreshold = pct
+	}
+}
+
+// WithFailureWindow sets the length of the sliding failure window
+func WithFailureWindow(d time.Duration) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.config.FailureWindow = d
+	}
+}
+
+// WithSuccessThreshold sets the number of half-open successes needed to close the circuit
+func WithSuccessThreshold(n int) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.config.SuccessThreshold = n
+	}
+}
+
+// WithHalfOpenMaxProbes sets the number of trial requests allowed through at once in half-open
+// Further callers are rejected with ErrTooManyProbes until a probe completes
+func WithHalfOpenMaxProbes(n int) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.config.HalfOpenMaxProbes = n
+	}
+}
+
+// WithOpenTimeout sets how long the circuit stays open before moving to half-open
+func WithOpenTimeout(d time.Duration) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.config.OpenTimeout = d
+	}
+}
+
+// WithMinimumRequests sets the number of calls in the window required before the circuit may open
+func WithMinimumRequests(n int) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.config.MinimumRequests = n
+	}
+}
+
+// WithIsFailure sets the predicate deciding which errors count against the circuit
+// Errors it rejects are passed through to the caller without being recorded
+// Defaults to DefaultIsFailure
+func WithIsFailure(fn func(error) bool) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.isFailure = fn
+	}
+}
+
+// WithEventHistorySize sets how many state transitions Events returns
+func WithEventHistorySize(n int) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.eventHistorySize = n
+	}
+}
+
+// BulkheadConfig holds the tunable settings of a bulkhead
+type BulkheadConfig struct {
+	// MaxConcurrent is the number of requests allowed to run at once
+	MaxConcurrent int
+	// MaxQueue is the number of requests allowed to wait for a permit
+	// Zero rejects requests beyond capacity immediately
+	MaxQueue int
+	// MaxWait is how long a queued request waits for a permit before giving up
+	// Zero waits until the request's context is done
+	MaxWait time.Duration
+}
+
+// DefaultBulkheadConfig returns 10 concurrent requests with no wait queue
+func DefaultBulkheadConfig() BulkheadConfig {
+	return BulkheadConfig{
+		MaxConcurrent: 10,
+		MaxQueue:      0,
+		MaxWait:       0,
+	}
+}
+
+// Validate reports whether the configuration can be used to build a bulkhead
+func (c BulkheadConfig) Validate() error {
+	switch {
+	case c.MaxConcurrent < 1:
+		return errors.New("max concurrent must be at least 1")
+	case c.MaxQueue < 0:
+		return errors.New("max queue must not be negative")
+	case c.MaxWait < 0:
+		return errors.New("max wait must not be negative")
+	}
+	return nil
+}
+
+// BulkheadOption configures a bulkhead
+type BulkheadOption func(*BulkheadConfig)
+
+// WithBulkheadConfig replaces all settings, including capacity, with the given configuration
+func WithBulkheadConfig(cfg BulkheadConfig) BulkheadOption {
+	return func(c *BulkheadConfig) {
+		*c = cfg
+	}
+}
+
+// WithMaxQueue sets the number of requests allowed to wait for a permit
+func WithMaxQueue(n int) BulkheadOption {
+	return func(c *BulkheadConfig) {
+		c.MaxQueue = n
+	}
+}
+
+// WithMaxWait sets how long a queued request waits for a permit
+func WithMaxWait(d time.Duration) BulkheadOption {
+	return func(c *BulkheadConfig) {
+		c.MaxWait = d
+	}
+}
+
+// AdaptiveLimiterConfig holds the tunable settings of an adaptive limiter
+type AdaptiveLimiterConfig struct {
+	// Enabled makes NewConcurrencyLimiter build an AdaptiveLimiter instead of a Bulkhead
+	Enabled bool
+	// Algorithm is one of LimitAlgorithmAIMD, LimitAlgorithmVegas or LimitAlgorithmGradient
+	Algorithm string
+	// InitialLimit is the concurrency limit used before any call completed
+	InitialLimit int
+	// MinLimit and MaxLimit bound the limit the algorithm may choose
+	MinLimit int
+	MaxLimit int
+}
+
+// DefaultAdaptiveLimiterConfig returns a disabled AIMD limiter starting at 10 within [1, 100]
+func DefaultAdaptiveLimiterConfig() AdaptiveLimiterConfig {
+	return AdaptiveLimiterConfig{
+		Enabled:      false,
+		Algorithm:    LimitAlgorithmAIMD,
+		InitialLimit: 10,
+		MinLimit:     1,
+		MaxLimit:     100,
+	}
+}
+
+// Validate reports whether the configuration can be used to build an adaptive limiter
+func (c AdaptiveLimiterConfig) Validate() error {
+	if _, err := newLimitAlgorithm(c.Algorithm); err != nil {
+		return err
+	}
+	switch {
+	case c.MinLimit < 1:
+		return errors.New("min limit must be at least 1")
+	case c.MaxLimit < c.MinLimit:
+		return errors.New("max limit must not be below min limit")
+	case c.InitialLimit < c.MinLimit || c.InitialLimit > c.MaxLimit:
+		return errors.New("initial limit must be between min limit and max limit")
+	}
+	return nil
+}
+
+// AdaptiveLimiterOption configures an adaptive limiter
+type AdaptiveLimiterOption func(*AdaptiveLimiterConfig)
+
+// WithAdaptiveLimiterConfig replaces all settings with the given configuration
+func WithAdaptiveLimiterConfig(cfg AdaptiveLimiterConfig) AdaptiveLimiterOption {
+	return func(c *AdaptiveLimiterConfig) {
+		*c = cfg
+	}
+}
+
+// WithLimitAlgorithm sets the algorithm that adjusts the limit
+func WithLimitAlgorithm(name string) AdaptiveLimiterOption {
+	return func(c *AdaptiveLimiterConfig) {
+		c.Algorithm = name
+	}
+}
+
+// WithInitialLimit sets the limit used before any call completed
+func WithInitialLimit(n int) AdaptiveLimiterOption {
+	return func(c *AdaptiveLimiterConfig) {
+		c.InitialLimit = n
+	}
+}
+
+// WithLimitBounds sets the lowest and highest limit the algorithm may choose
+func WithLimitBounds(minLimit, maxLimit int) AdaptiveLimiterOption {
+	return func(c *AdaptiveLimiterConfig) {
+		c.MinLimit = minLimit
+		c.MaxLimit = maxLimit
+	}
+}
+
+// RetryConfig holds the tunable settings of a retry policy
+type RetryConfig struct {
+	// MaxAttempts is the number of calls made at most, the first one included
+	MaxAttempts int
+	// BaseBackoff is the backoff ceiling after the first attempt, doubling with each retry
+	BaseBackoff time.Duration
+	// MaxBackoff caps the backoff ceiling
+	MaxBackoff time.Duration
+	// BudgetRatio is the number of retries each call adds to the budget, e.g. 0.1 allows
+	// retrying one call in ten over time
+	BudgetRatio float64
+	// BudgetCapacity is the most retries the budget can hold, allowing short bursts
+	BudgetCapacity int
+}
+
+// DefaultRetryConfig returns 3 attempts backing off from 100ms up to 1s,
+// with a budget of 10% of calls and bursts of up to 10 retries
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts:    3,
+		BaseBackoff:    100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		BudgetRatio:    0.1,
+		BudgetCapacity: 10,
+	}
+}
+
+// Validate reports whether the configuration can be used to build a retry policy
+func (c RetryConfig) Validate() error {
+	switch {
+	case c.MaxAttempts < 1:
+		return errors.New("max attempts must be at least 1")
+	case c.BaseBackoff < 0:
+		return errors.New("base backoff must not be negative")
+	case c.MaxBackoff < c.BaseBackoff:
+		return errors.New("max backoff must not be below base backoff")
+	case c.BudgetRatio < 0:
+		return errors.New("budget ratio must not be negative")
+	case c.BudgetCapacity < 0:
+		return errors.New("budget capacity must not be negative")
+	}
+	return nil
+}
+
+// retryOptions collects the settings applied by RetryOption
+type retryOptions struct {
+	config      RetryConfig
+	isRetryable func(error) bool
+}
+
+// RetryOption configures a retry policy
+type RetryOption func(*retryOptions)
+
+// WithRetryConfig replaces all numeric settings with the given configuration
+func WithRetryConfig(cfg RetryConfig) RetryOption {
+	return func(o *retryOptions) {
+		o.config = cfg
+	}
+}
+
+// WithMaxAttempts sets the number of calls made at most, the first one included
+func WithMaxAttempts(n int) RetryOption {
+	return func(o *retryOptions) {
+		o.config.MaxAttempts = n
+	}
+}
+
+// WithBackoff sets the backoff ceiling after the first attempt and its cap
+func WithBackoff(base, maxBackoff time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.config.BaseBackoff = base
+		o.config.MaxBackoff = maxBackoff
+	}
+}
+
+// WithRetryBudget sets the retries earned per call and the most the budget can hold
+func WithRetryBudget(ratio float64, capacity int) RetryOption {
+	return func(o *retryOptions) {
+		o.config.BudgetRatio = ratio
+		o.config.BudgetCapacity = capacity
+	}
+}
+
+// WithRetryable sets the predicate deciding whether an error may be retried
+// The default is DefaultIsRetryable
+func WithRetryable(isRetryable func(error) bool) RetryOption {
+	return func(o *retryOptions) {
+		o.isRetryable = isRetryable
+	}
+}
+
+// IdempotencyConfig holds the tunable settings of an idempotency store
+type IdempotencyConfig struct {
+	// TTL is how long the response to an idempotency key is kept
+	TTL time.Duration
+}
+
+// DefaultIdempotencyConfig returns responses kept for 24 hours
+func DefaultIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		TTL: 24 * time.Hour,
+	}
+}
+
+// Validate reports whether the configuration can be used to build an idempotency store
+func (c IdempotencyConfig) Validate() error {
+	if c.TTL <= 0 {
+		return errors.New("ttl must be positive")
+	}
+	return nil
+}
+
+// HedgeConfig holds the tunable settings of a hedging policy
+type HedgeConfig struct {
+	// Enabled turns hedging on; when off every call is made exactly once
+	Enabled bool
+	// Delay is how long the first request may take before a hedge is sent
+	// It is used until enough latencies are observed when Percentile is set
+	Delay time.Duration
+	// Percentile derives the delay from observed latencies, e.g. 95 hedges
+	// requests slower than the p95; zero always uses Delay
+	Percentile float64
+	// MaxInFlight caps the hedge requests running at once
+	MaxInFlight int
+}
+
+// DefaultHedgeConfig returns hedging disabled, hedging at the p95 latency
+// (50ms until measured) with at most 10 hedges in flight once enabled
+func DefaultHedgeConfig() HedgeConfig {
+	return HedgeConfig{
+		Enabled:     false,
+		Delay:       50 * time.Millisecond,
+		Percentile:  95,
+		MaxInFlight: 10,
+	}
+}
+
+// Validate reports whether the configuration can be used to build a hedging policy
+func (c HedgeConfig) Validate() error {
+	switch {
+	case c.Delay < 0:
+		return errors.New("delay must not be negative")
+	case c.Percentile < 0 || c.Percentile > 100:
+		return errors.New("percentile must be between 0 and 100")
+	case c.MaxInFlight < 0:
+		return errors.New("max in flight must not be negative")
+	}
+	return nil
+}
+
+// HedgeOption configures a hedging policy
+type HedgeOption func(*HedgeConfig)
+
+// WithHedgeConfig replaces all settings with the given configuration
+func WithHedgeConfig(cfg HedgeConfig) HedgeOption {
+	return func(c *HedgeConfig) {
+		*c = cfg
+	}
+}
+
+// WithHedgeDelay enables hedging after a fixed delay
+func WithHedgeDelay(d time.Duration) HedgeOption {
+	return func(c *HedgeConfig) {
+		c.Enabled = true
+		c.Delay = d
+		c.Percentile = 0
+	}
+}
+
+// WithHedgePercentile enables hedging after the given percentile of observed latencies
+// fallback is used until enough latencies are observed
+func WithHedgePercentile(percentile float64, fallback time.Duration) HedgeOption {
+	return func(c *HedgeConfig) {
+		c.Enabled = true
+		c.Delay = fallback
+		c.Percentile = percentile
+	}
+}
+
+// WithMaxHedgesInFlight caps the hedge requests running at once
+func WithMaxHedgesInFlight(n int) HedgeOption {
+	return func(c *HedgeConfig) {
+		c.MaxInFlight = n
+	}
+}
+
+// DeadlineConfig holds the deadline settings of a service's incoming requests
+type DeadlineConfig struct {
+	// Timeout is the budget of requests arriving without a deadline, and caps those with one
+	// Zero leaves requests without a deadline unbounded
+	Timeout time.Duration
+	// Floor is the least budget worth starting work for; requests with less are rejected
+	Floor time.Duration
+}
+
+// DefaultDeadlineConfig returns no timeout of its own and a 50ms floor
+func DefaultDeadlineConfig() DeadlineConfig {
+	return DeadlineConfig{
+		Timeout: 0,
+		Floor:   50 * time.Millisecond,
+	}
+}
+
+// Validate reports whether the configuration can be used to derive request deadlines
+func (c DeadlineConfig) Validate() error {
+	switch {
+	case c.Timeout < 0:
+		return errors.New("timeout must not be negative")
+	case c.Floor < 0:
+		return errors.New("floor must not be negative")
+	case c.Timeout > 0 && c.Floor > c.Timeout:
+		return errors.New("floor must not exceed timeout")
+	}
+	return nil
+}
+
+// Rate limit keys, identifying who a rate limit applies to
+const (
+	RateLimitKeyAPIKey     = "api_key"
+	RateLimitKeyCustomerID = "customer_id"
+	RateLimitKeyIP         = "ip"
+)
+
+// RateLimitRule is the rate limit of a single route
+type RateLimitRule struct {
+	// Rate is the sustained number of requests allowed per second
+	Rate float64
+	// Burst is the number of requests allowed at once
+	Burst int
+	// Key is one of RateLimitKeyAPIKey, RateLimitKeyCustomerID or RateLimitKeyIP
+	// Requests without the key fall back to the client IP
+	Key string
+}
+
+// Validate reports whether the rule can be used to build a rate limiter
+func (r RateLimitRule) Validate() error {
+	switch {
+	case r.Rate <= 0:
+		return errors.New("rate must be positive")
+	case r.Burst < 1:
+		return errors.New("burst must be at least 1")
+	case r.Key != RateLimitKeyAPIKey && r.Key != RateLimitKeyCustomerID && r.Key != RateLimitKeyIP:
+		return fmt.Errorf("unknown key %q", r.Key)
+	}
+	return nil
+}
+
+// RateLimitConfig holds the rate limits of a service
+type RateLimitConfig struct {
+	// Routes maps a route, "METHOD /path" as registered with the router, to its rule
+	// Routes not listed are not limited
+	Routes map[string]RateLimitRule
+}
+
+// Validate reports whether every rule is valid
+func (c RateLimitConfig) Validate() error {
+	for route, rule := range c.Routes {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("route %q: %w", route, err)
+		}
+	}
+	return nil
+}
+
+// LoadShedderConfig holds the tunable settings of a load shedder
+type LoadShedderConfig struct {
+	// MaxInFlight is the number of requests served at once before new ones queue
+	MaxInFlight int
+	// MaxQueue is the number of requests allowed to wait; lower priority waiters
+	// are dropped to make room for higher priority ones
+	MaxQueue int
+	// Target is the queueing latency CoDel tolerates
+	Target time.Duration
+	// Interval is how long queueing latency must stay above Target before the
+	// service counts as overloaded, and the longest a request waits when it is not
+	Interval time.Duration
+}
+
+// DefaultLoadShedderConfig returns 100 requests in flight, a queue of 50
+// and the CoDel defaults of a 5ms target over a 100ms interval
+func DefaultLoadShedderConfig() LoadShedderConfig {
+	return LoadShedderConfig{
+		MaxInFlight: 100,
+		MaxQueue:    50,
+		Target:      5 * time.Millisecond,
+		Interval:    100 * time.Millisecond,
+	}
+}
+
+// Validate reports whether the configuration can be used to build a load shedder
+func (c LoadShedderConfig) Validate() error {
+	switch {
+	case c.MaxInFlight < 1:
+		return errors.New("max in flight must be at least 1")
+	case c.MaxQueue < 0:
+		return errors.New("max queue must not be negative")
+	case c.Target <= 0:
+		return errors.New("target must be positive")
+	case c.Interval < c.Target:
+		return errors.New("interval must not be shorter than target")
+	}
+	return nil
+}
+
+// CacheConfig holds the tunable settings of a response cache
+type CacheConfig struct {
+	// MaxEntries bounds the cache; the least recently used entry is evicted beyond it
+	MaxEntries int
+	// TTL is how long an entry is served without asking the origin
+	TTL time.Duration
+	// MaxStale is how long past its TTL an entry may still be served when the origin fails
+	MaxStale time.Duration
+}
+
+// DefaultCacheConfig returns 1000 entries fresh for 5 seconds and served
+// for up to 10 minutes more while the origin is failing
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		MaxEntries: 1000,
+		TTL:        5 * time.Second,
+		MaxStale:   10 * time.Minute,
+	}
+}
+
+// Validate reports whether the configuration can be used to build a cache
+func (c CacheConfig) Validate() error {
+	switch {
+	case c.MaxEntries < 1:
+		return errors.New("max entries must be at least 1")
+	case c.TTL < 0:
+		return errors.New("ttl must not be negative")
+	case c.MaxStale < 0:
+		return errors.New("max stale must not be negative")
+	}
+	return nil
+}
+
+// cacheOptions collects the settings applied by CacheOption
+type cacheOptions struct {
+	config  CacheConfig
+	staleIf func(error) bool
+}
+
+// CacheOption configures a response cache
+type CacheOption func(*cacheOptions)
+
+// WithCacheConfig replaces all numeric settings with the given configuration
+func WithCacheConfig(cfg CacheConfig) CacheOption {
+	return func(o *cacheOptions) {
+		o.config = cfg
+	}
+}
+
+// WithStaleIf sets the predicate deciding which errors a stale entry may be served for
+// The default is DefaultIsFailure, so stale entries cover outages but not rejections
+func WithStaleIf(staleIf func(error) bool) CacheOption {
+	return func(o *cacheOptions) {
+		o.staleIf = staleIf
+	}
+}
+
+// FallbackConfig decides which failures a degraded response covers, per error type,
+// and how the work accepted under it is retried later
+type FallbackConfig struct {
+	// OnCircuitOpen covers ErrCircuitOpen
+	OnCircuitOpen bool
+	// OnBulkheadFull covers ErrBulkheadFull, including queue timeouts and adaptive limits
+	OnBulkheadFull bool
+	// OnTimeout covers context.DeadlineExceeded
+	OnTimeout bool
+	// OnServerError covers retryable upstream errors such as 5xx responses
+	OnServerError bool
+	// RetryInterval is how often accepted work is retried
+	RetryInterval time.Duration
+	// MaxAttempts is how many failed retries are made before giving up
+	// Retries rejected by an open circuit or a full bulkhead are not counted
+	MaxAttempts int
+}
+
+// DefaultFallbackConfig returns a fallback covering an open circuit and a full bulkhead,
+// retried every 5 seconds up to 10 times
+func DefaultFallbackConfig() FallbackConfig {
+	return FallbackConfig{
+		OnCircuitOpen:  true,
+		OnBulkheadFull: true,
+		RetryInterval:  5 * time.Second,
+		MaxAttempts:    10,
+	}
+}
+
+// Validate reports whether the configuration can be used to run a fallback
+func (c FallbackConfig) Validate() error {
+	switch {
+	case c.RetryInterval <= 0:
+		return errors.New("retry interval must be positive")
+	case c.MaxAttempts < 1:
+		return errors.New("max attempts must be at least 1")
+	}
+	return nil
+}
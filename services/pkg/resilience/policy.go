@@ -0,0 +1,65 @@
+// Package resilience provides the fault tolerance policies shared by the
+// go-down services: circuit breakers, bulkheads and the policies built on them
+package resilience
+
+import "context"
+
+// Policy is implemented by every resilience policy in this package
+// It lets callers treat circuit breakers, bulkheads and future policies uniformly
+type Policy interface {
+	// Name returns the name the policy reports its metrics under
+	Name() string
+
+	// Do runs fn under the protection of the policy
+	Do(ctx context.Context, fn func() error) error
+}
+
+// ConcurrencyLimiter bounds the number of calls in flight
+// Bulkhead and AdaptiveLimiter both implement it, so callers can switch between
+// fixed and adaptive limiting through configuration
+type ConcurrencyLimiter interface {
+	Policy
+	ManagedBulkhead
+
+	// Execute runs fn once the limiter admits it
+	// Rejections wrap ErrBulkheadFull
+	Execute(ctx context.Context, fn func() error) error
+}
+
+// NewConcurrencyLimiter builds the limiter selected by configuration
+// It returns an AdaptiveLimiter when adaptive.Enabled is set and a Bulkhead otherwise,
+// or an error if the adaptive configuration is invalid
+func NewConcurrencyLimiter(poolName string, bulkhead BulkheadConfig, adaptive AdaptiveLimiterConfig) (ConcurrencyLimiter, error) {
+	if adaptive.Enabled {
+		return NewAdaptiveLimiter(poolName, WithAdaptiveLimiterConfig(adaptive))
+	}
+	return NewBulkhead(poolName, bulkhead.MaxConcurrent, WithBulkheadConfig(bulkhead)), nil
+}
+
+// Chain composes policies into one, the first policy being the outermost
+// e.g. Chain(retry, bulkhead, breaker) runs each retry attempt through the
+// bulkhead and then the circuit breaker
+func Chain(policies ...Policy) Policy {
+	return policyChain(policies)
+}
+
+// policyChain runs a call through a list of policies, outermost first
+type policyChain []Policy
+
+// Name returns the name of the outermost policy
+func (c policyChain) Name() string {
+	if len(c) == 0 {
+		return ""
+	}
+	return c[0].Name()
+}
+
+// Do runs fn through every policy of the chain
+func (c policyChain) Do(ctx context.Context, fn func() error) error {
+	if len(c) == 0 {
+		return fn()
+	}
+	return c[0].Do(ctx, func() error {
+		return c[1:].Do(ctx, fn)
+	})
+}
@@ -0,0 +1,163 @@
+package resilience
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Request results reported by rate_limit_requests_total
+const (
+	rateLimitResultAllowed   = "allowed"
+	rateLimitResultThrottled = "throttled"
+)
+
+var rateLimitRequests = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "rate_limit_requests_total",
+		Help: "Total number of requests checked against a rate limit by route and result (allowed, throttled)",
+	},
+	[]string{"route", "result"},
+)
+
+// RateLimitDecision is the outcome of checking a request against a rate limit
+type RateLimitDecision struct {
+	Allowed bool
+	// Limit is the number of requests allowed in a burst
+	Limit int
+	// Remaining is the number of requests that could still be made right now
+	Remaining int
+	// Reset is how long until the full burst is available again
+	Reset time.Duration
+	// RetryAfter is how long a throttled client should wait before trying again
+	RetryAfter time.Duration
+}
+
+// RateLimiter limits requests per key with the generic cell rate algorithm (GCRA),
+// which behaves like a token bucket refilled at Rate holding up to Burst tokens
+// but only stores one timestamp per key
+type RateLimiter struct {
+	mu        sync.Mutex
+	rule      RateLimitRule
+	interval  time.Duration
+	tats      map[string]time.Time // theoretical arrival time per key
+	lastSweep time.Time
+	route     string
+}
+
+// NewRateLimiter creates a rate limiter for route applying rule to each key
+func NewRateLimiter(route string, rule RateLimitRule) *RateLimiter {
+	rateLimitRequests.WithLabelValues(route, rateLimitResultAllowed).Add(0)
+	rateLimitRequests.WithLabelValues(route, rateLimitResultThrottled).Add(0)
+
+	return &RateLimiter{
+		rule:      rule,
+		interval:  time.Duration(float64(time.Second) / rule.Rate),
+		tats:      make(map[string]time.Time),
+		lastSweep: time.Now(),
+		route:     route,
+	}
+}
+
+// Allow checks a request made by key against the limit, consuming a token if allowed
+func (l *RateLimiter) Allow(key string) RateLimitDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	burst := time.Duration(l.rule.Burst) * l.interval
+	tat := l.tats[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(l.interval)
+
+	decision := RateLimitDecision{Limit: l.rule.Burst}
+	if allowAt := newTAT.Add(-burst); now.Before(allowAt) {
+		decision.RetryAfter = allowAt.Sub(now)
+		decision.Reset = tat.Sub(now)
+		rateLimitRequests.WithLabelValues(l.route, rateLimitResultThrottled).Inc()
+		return decision
+	}
+
+	l.tats[key] = newTAT
+	decision.Allowed = true
+	decision.Remaining = int(math.Floor(float64(burst-newTAT.Sub(now)) / float64(l.interval)))
+	decision.Reset = newTAT.Sub(now)
+	rateLimitRequests.WithLabelValues(l.route, rateLimitResultAllowed).Inc()
+	return decision
+}
+
+// Rule returns the rule the limiter applies
+func (l *RateLimiter) Rule() RateLimitRule {
+	return l.rule
+}
+
+// sweep forgets keys whose bucket has refilled, at most once a minute
+// Callers hold l.mu
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	for key, tat := range l.tats {
+		if tat.Before(now) {
+			delete(l.tats, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RateLimits holds the rate limiter of every limited route
+// Update swaps in new rules without a restart
+type RateLimits struct {
+	mu       sync.RWMutex
+	limiters map[string]*RateLimiter
+}
+
+// NewRateLimits creates the rate limiters configured by cfg
+func NewRateLimits(cfg RateLimitConfig) *RateLimits {
+	r := &RateLimits{limiters: make(map[string]*RateLimiter)}
+	r.Update(cfg)
+	return r
+}
+
+// Update applies new rules
+// Routes whose rule is unchanged keep their limiter, so clients don't get a fresh burst
+func (r *RateLimits) Update(cfg RateLimitConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limiters := make(map[string]*RateLimiter, len(cfg.Routes))
+	for route, rule := range cfg.Routes {
+		if existing, ok := r.limiters[route]; ok && existing.Rule() == rule {
+			limiters[route] = existing
+			continue
+		}
+		limiters[route] = NewRateLimiter(route, rule)
+	}
+	r.limiters = limiters
+}
+
+// Limiter returns the rate limiter of route, if it is limited
+func (r *RateLimits) Limiter(route string) (*RateLimiter, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	l, ok := r.limiters[route]
+	return l, ok
+}
+
+// Rules returns the rule of every limited route
+func (r *RateLimits) Rules() map[string]RateLimitRule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rules := make(map[string]RateLimitRule, len(r.limiters))
+	for route, l := range r.limiters {
+		rules[route] = l.Rule()
+	}
+	return rules
+}
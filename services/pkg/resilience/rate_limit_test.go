@@ -0,0 +1,80 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRateLimiterBurstThenThrottle(t *testing.T) {
+	const route = "POST /test-burst"
+	l := NewRateLimiter(route, RateLimitRule{Rate: 1, Burst: 3, Key: RateLimitKeyIP})
+	throttled := testutil.ToFloat64(rateLimitRequests.WithLabelValues(route, rateLimitResultThrottled))
+	allowed := testutil.ToFloat64(rateLimitRequests.WithLabelValues(route, rateLimitResultAllowed))
+
+	for i := 0; i < 3; i++ {
+		d := l.Allow("client")
+		if !d.Allowed || d.Remaining != 2-i || d.Limit != 3 {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i+1, d, 2-i)
+		}
+	}
+
+	d := l.Allow("client")
+	if d.Allowed {
+		t.Fatal("request beyond burst was allowed")
+	}
+	if d.RetryAfter <= 0 || d.RetryAfter > time.Second {
+		t.Fatalf("RetryAfter = %v, want up to 1s", d.RetryAfter)
+	}
+
+	// Keys are limited independently
+	if d := l.Allow("other"); !d.Allowed {
+		t.Fatal("other client was throttled")
+	}
+
+	if got := testutil.ToFloat64(rateLimitRequests.WithLabelValues(route, rateLimitResultThrottled)) - throttled; got != 1 {
+		t.Fatalf("throttled = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(rateLimitRequests.WithLabelValues(route, rateLimitResultAllowed)) - allowed; got != 4 {
+		t.Fatalf("allowed = %v, want 4", got)
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	l := NewRateLimiter("GET /test-refill", RateLimitRule{Rate: 100, Burst: 1, Key: RateLimitKeyIP})
+
+	if !l.Allow("client").Allowed {
+		t.Fatal("first request throttled")
+	}
+	if l.Allow("client").Allowed {
+		t.Fatal("second request allowed before refill")
+	}
+	time.Sleep(15 * time.Millisecond)
+	if !l.Allow("client").Allowed {
+		t.Fatal("request throttled after refill")
+	}
+}
+
+func TestRateLimitsUpdate(t *testing.T) {
+	rule := RateLimitRule{Rate: 1, Burst: 1, Key: RateLimitKeyIP}
+	r := NewRateLimits(RateLimitConfig{Routes: map[string]RateLimitRule{"GET /a": rule, "GET /b": rule}})
+
+	a, _ := r.Limiter("GET /a")
+	a.Allow("client")
+
+	r.Update(RateLimitConfig{Routes: map[string]RateLimitRule{"GET /a": rule, "GET /c": rule}})
+
+	if same, _ := r.Limiter("GET /a"); same != a {
+		t.Fatal("unchanged rule got a new limiter")
+	}
+	if same, _ := r.Limiter("GET /a"); same.Allow("client").Allowed {
+		t.Fatal("unchanged rule lost its state")
+	}
+	if _, ok := r.Limiter("GET /b"); ok {
+		t.Fatal("removed route is still limited")
+	}
+	if _, ok := r.Limiter("GET /c"); !ok {
+		t.Fatal("added route is not limited")
+	}
+}
@@ -0,0 +1,114 @@
+package resilience
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerSnapshot is a point-in-time view of a circuit breaker
+type CircuitBreakerSnapshot struct {
+	Name              string
+	State             CircuitState
+	Forced            bool
+	RequestsInWindow  int
+	FailuresInWindow  int
+	SlowCallsInWindow int
+	HalfOpenProbes    int
+	TimeUntilHalfOpen time.Duration
+}
+
+// BulkheadSnapshot is a point-in-time view of a bulkhead
+type BulkheadSnapshot struct {
+	Name          string
+	Algorithm     string // "fixed" for a Bulkhead, the limit algorithm for an AdaptiveLimiter
+	MaxConcurrent int
+	Active        int
+	Available     int
+	Queued        int
+	MaxQueue      int
+}
+
+// ManagedCircuitBreaker is the type-independent view of a CircuitBreaker kept by a Registry
+// It lets operators inspect and override circuit breakers regardless of their result type
+type ManagedCircuitBreaker interface {
+	Name() string
+	Snapshot() CircuitBreakerSnapshot
+	Events() []StateChangeEvent
+	ForceOpen()
+	ForceClose()
+	Reset()
+}
+
+// ManagedBulkhead is the view of a concurrency limiter kept by a Registry
+type ManagedBulkhead interface {
+	Name() string
+	Snapshot() BulkheadSnapshot
+}
+
+// Registry tracks the circuit breakers and bulkheads of a service by name
+type Registry struct {
+	mu              sync.RWMutex
+	circuitBreakers map[string]ManagedCircuitBreaker
+	bulkheads       map[string]ManagedBulkhead
+}
+
+// DefaultRegistry is the registry NewCircuitBreaker and NewBulkhead register into
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		circuitBreakers: make(map[string]ManagedCircuitBreaker),
+		bulkheads:       make(map[string]ManagedBulkhead),
+	}
+}
+
+// RegisterCircuitBreaker adds a circuit breaker, replacing any with the same name
+func (r *Registry) RegisterCircuitBreaker(cb ManagedCircuitBreaker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.circuitBreakers[cb.Name()] = cb
+}
+
+// RegisterBulkhead adds a bulkhead, replacing any with the same name
+func (r *Registry) RegisterBulkhead(b ManagedBulkhead) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bulkheads[b.Name()] = b
+}
+
+// CircuitBreaker looks up a circuit breaker by name
+func (r *Registry) CircuitBreaker(name string) (ManagedCircuitBreaker, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cb, ok := r.circuitBreakers[name]
+	return cb, ok
+}
+
+// CircuitBreakers returns every registered circuit breaker sorted by name
+func (r *Registry) CircuitBreakers() []ManagedCircuitBreaker {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedByName(r.circuitBreakers)
+}
+
+// Bulkheads returns every registered bulkhead sorted by name
+func (r *Registry) Bulkheads() []ManagedBulkhead {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedByName(r.bulkheads)
+}
+
+// sortedByName returns the values of a registry map ordered by name
+func sortedByName[V interface{ Name() string }](m map[string]V) []V {
+	out := make([]V, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	slices.SortFunc(out, func(a, b V) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return out
+}
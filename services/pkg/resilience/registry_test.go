@@ -0,0 +1,67 @@
+package resilience
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConstructorsRegister(t *testing.T) {
+	NewCircuitBreaker[int]("test-registry-breaker")
+	NewBulkhead("test-registry-pool", 3)
+
+	cb, ok := DefaultRegistry.CircuitBreaker("test-registry-breaker")
+	if !ok {
+		t.Fatal("circuit breaker not registered")
+	}
+	if got := cb.Snapshot().State; got != StateClosed {
+		t.Fatalf("state = %v, want closed", got)
+	}
+
+	found := false
+	for _, b := range DefaultRegistry.Bulkheads() {
+		if b.Name() == "test-registry-pool" {
+			found = true
+			if got := b.Snapshot(); got.MaxConcurrent != 3 || got.Available != 3 {
+				t.Fatalf("snapshot = %+v, want 3 available of 3", got)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("bulkhead not registered")
+	}
+}
+
+func TestCircuitBreakerForceOpenAndClose(t *testing.T) {
+	cb := NewCircuitBreaker[int]("test-force",
+		WithFailureThreshold(1),
+		WithOpenTimeout(10*time.Millisecond),
+	)
+
+	cb.ForceOpen()
+	time.Sleep(20 * time.Millisecond)
+	if _, err := cb.Execute(func() (int, error) { return 1, nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err while forced open = %v, want ErrCircuitOpen", err)
+	}
+	if snap := cb.Snapshot(); !snap.Forced || snap.TimeUntilHalfOpen != 0 {
+		t.Fatalf("snapshot = %+v, want forced with no half-open countdown", snap)
+	}
+
+	cb.ForceClose()
+	failN(cb, 3)
+	if got := cb.GetState(); got != StateClosed {
+		t.Fatalf("state while forced closed = %v, want closed", got)
+	}
+
+	cb.Reset()
+	if snap := cb.Snapshot(); snap.Forced || snap.RequestsInWindow != 0 {
+		t.Fatalf("snapshot after reset = %+v, want unforced and empty", snap)
+	}
+	failN(cb, 1)
+	if got := cb.GetState(); got != StateOpen {
+		t.Fatalf("state after reset and failure = %v, want open", got)
+	}
+	if snap := cb.Snapshot(); snap.TimeUntilHalfOpen <= 0 {
+		t.Fatalf("TimeUntilHalfOpen = %v, want positive", snap.TimeUntilHalfOpen)
+	}
+}
@@ -0,0 +1,211 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Attempt results reported by retry_attempts_total
+const (
+	attemptResultSuccess = "success"
+	attemptResultRetried = "retried"
+	attemptResultFailure = "failure"
+)
+
+var (
+	retryAttempts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retry_attempts_total",
+			Help: "Total number of attempts made by a retry policy by attempt number and result (success, retried, failure)",
+		},
+		[]string{"service", "attempt", "result"},
+	)
+
+	retryBudgetExhausted = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retry_budget_exhausted_total",
+			Help: "Total number of retries skipped because the retry budget was empty",
+		},
+		[]string{"service"},
+	)
+
+	retryBudgetTokens = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "retry_budget_tokens",
+			Help: "Retries currently available in the retry budget",
+		},
+		[]string{"service"},
+	)
+)
+
+// Retry repeats failed calls with exponential backoff and full jitter
+// A token bucket budget caps retries to a fraction of calls, so retries
+// cannot multiply the load on a service that is already failing
+//
+// Compose it outside the bulkhead and circuit breaker:
+//
+//	Retry -> Bulkhead -> CircuitBreaker -> call
+//
+// so every attempt is counted by the circuit breaker, an open circuit ends the
+// retries (ErrCircuitOpen is not retryable), and no permit is held while backing off
+type Retry struct {
+	mu             sync.Mutex
+	maxAttempts    int
+	baseBackoff    time.Duration
+	maxBackoff     time.Duration
+	budgetRatio    float64
+	budgetCapacity float64
+	tokens         float64
+	isRetryable    func(error) bool
+	serviceName    string
+}
+
+// NewRetry creates a retry policy
+// Settings default to DefaultRetryConfig and can be overridden with options
+func NewRetry(serviceName string, opts ...RetryOption) *Retry {
+	o := retryOptions{
+		config:      DefaultRetryConfig(),
+		isRetryable: DefaultIsRetryable,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	cfg := o.config
+
+	r := &Retry{
+		maxAttempts:    cfg.MaxAttempts,
+		baseBackoff:    cfg.BaseBackoff,
+		maxBackoff:     cfg.MaxBackoff,
+		budgetRatio:    cfg.BudgetRatio,
+		budgetCapacity: float64(cfg.BudgetCapacity),
+		tokens:         float64(cfg.BudgetCapacity),
+		isRetryable:    o.isRetryable,
+		serviceName:    serviceName,
+	}
+
+	// Initialize metrics so they show up in Grafana immediately
+	retryBudgetExhausted.WithLabelValues(serviceName).Add(0)
+	retryBudgetTokens.WithLabelValues(serviceName).Set(r.tokens)
+
+	return r
+}
+
+// Do runs fn, retrying retryable errors until it succeeds, the attempts or
+// budget run out, or ctx is done or would be before the next attempt
+// It returns the error of the last attempt
+func (r *Retry) Do(ctx context.Context, fn func() error) error {
+	return r.do(ctx, r.isRetryable, fn)
+}
+
+// UnsentOnly returns r restricted to errors proving the call never reached the
+// service (see IsUnsent), for calls that are not safe to repeat such as a POST
+// without an idempotency key
+// The returned policy shares the budget and metrics of r
+func (r *Retry) UnsentOnly() Policy {
+	return unsentRetry{r}
+}
+
+// unsentRetry is a retry policy that only repeats calls that were never sent
+type unsentRetry struct {
+	*Retry
+}
+
+// Do runs fn like Retry.Do, but only retries errors that are also unsent
+func (u unsentRetry) Do(ctx context.Context, fn func() error) error {
+	return u.do(ctx, func(err error) bool {
+		return u.isRetryable(err) && IsUnsent(err)
+	}, fn)
+}
+
+// do runs fn, retrying the errors isRetryable accepts
+func (r *Retry) do(ctx context.Context, isRetryable func(error) bool, fn func() error) error {
+	r.deposit()
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			if err == nil {
+				err = ctxErr
+			}
+			return err
+		}
+
+		err = fn()
+		label := strconv.Itoa(attempt)
+		if err == nil {
+			retryAttempts.WithLabelValues(r.serviceName, label, attemptResultSuccess).Inc()
+			return nil
+		}
+		// Don't retry when the caller's deadline passes before the backoff ends
+		wait := r.backoff(attempt)
+		if attempt >= r.maxAttempts || !isRetryable(err) || !outlives(ctx, wait) || !r.withdraw() {
+			retryAttempts.WithLabelValues(r.serviceName, label, attemptResultFailure).Inc()
+			return err
+		}
+		retryAttempts.WithLabelValues(r.serviceName, label, attemptResultRetried).Inc()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Name returns the service name the retry policy reports metrics under
+func (r *Retry) Name() string {
+	return r.serviceName
+}
+
+// backoff returns a random delay between zero and the exponential backoff of the attempt
+// ("full jitter"), so clients that failed together don't retry together
+func (r *Retry) backoff(attempt int) time.Duration {
+	ceiling := r.maxBackoff
+	if shift := attempt - 1; shift < 32 {
+		if d := r.baseBackoff << shift; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// deposit adds the budget earned by a call
+func (r *Retry) deposit() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens = min(r.budgetCapacity, r.tokens+r.budgetRatio)
+	retryBudgetTokens.WithLabelValues(r.serviceName).Set(r.tokens)
+}
+
+// withdraw takes a token for a retry, reporting false if the budget is empty
+func (r *Retry) withdraw() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tokens < 1 {
+		retryBudgetExhausted.WithLabelValues(r.serviceName).Inc()
+		return false
+	}
+	r.tokens--
+	retryBudgetTokens.WithLabelValues(r.serviceName).Set(r.tokens)
+	return true
+}
+
+// DefaultIsRetryable decides whether a failed call may be repeated
+// Only upstream errors marked retryable qualify: transport errors, 5xx, 408 and 429
+// Rejections by a circuit breaker or bulkhead and cancellations are final
+func DefaultIsRetryable(err error) bool {
+	var upstreamErr *UpstreamError
+	return errors.As(err, &upstreamErr) && upstreamErr.Retryable
+}
@@ -0,0 +1,181 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var errRetryable = NewStatusError("test", http.StatusServiceUnavailable, "")
+
+func TestRetryUntilSuccess(t *testing.T) {
+	const name = "test-retry-success"
+	r := NewRetry(name, WithBackoff(time.Millisecond, time.Millisecond))
+	retried := testutil.ToFloat64(retryAttempts.WithLabelValues(name, "1", attemptResultRetried))
+	succeeded := testutil.ToFloat64(retryAttempts.WithLabelValues(name, "3", attemptResultSuccess))
+
+	calls := 0
+	err := r.Do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return errRetryable
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("err = %v after %d calls, want success after 3", err, calls)
+	}
+
+	if got := testutil.ToFloat64(retryAttempts.WithLabelValues(name, "1", attemptResultRetried)) - retried; got != 1 {
+		t.Fatalf("attempt 1 retried = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(retryAttempts.WithLabelValues(name, "3", attemptResultSuccess)) - succeeded; got != 1 {
+		t.Fatalf("attempt 3 success = %v, want 1", got)
+	}
+}
+
+func TestRetryStopsAtMaxAttempts(t *testing.T) {
+	r := NewRetry("test-retry-max", WithMaxAttempts(2), WithBackoff(time.Millisecond, time.Millisecond))
+
+	calls := 0
+	err := r.Do(context.Background(), func() error {
+		calls++
+		return errRetryable
+	})
+	if !errors.Is(err, errRetryable) || calls != 2 {
+		t.Fatalf("err = %v after %d calls, want errRetryable after 2", err, calls)
+	}
+}
+
+func TestRetrySkipsNonRetryableErrors(t *testing.T) {
+	r := NewRetry("test-retry-final", WithBackoff(time.Millisecond, time.Millisecond))
+
+	for _, final := range []error{
+		NewStatusError("test", http.StatusBadRequest, ""),
+		ErrCircuitOpen,
+		ErrBulkheadFull,
+		errors.New("failed to decode response"),
+	} {
+		calls := 0
+		_ = r.Do(context.Background(), func() error {
+			calls++
+			return final
+		})
+		if calls != 1 {
+			t.Fatalf("%v: %d calls, want 1", final, calls)
+		}
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	const name = "test-retry-budget"
+	r := NewRetry(name, WithMaxAttempts(5), WithBackoff(0, 0), WithRetryBudget(0, 2))
+	exhausted := testutil.ToFloat64(retryBudgetExhausted.WithLabelValues(name))
+
+	calls := 0
+	_ = r.Do(context.Background(), func() error {
+		calls++
+		return errRetryable
+	})
+	if calls != 3 {
+		t.Fatalf("%d calls, want 3 (1 attempt + 2 budgeted retries)", calls)
+	}
+
+	calls = 0
+	_ = r.Do(context.Background(), func() error {
+		calls++
+		return errRetryable
+	})
+	if calls != 1 {
+		t.Fatalf("%d calls with empty budget, want 1", calls)
+	}
+	if got := testutil.ToFloat64(retryBudgetExhausted.WithLabelValues(name)) - exhausted; got != 2 {
+		t.Fatalf("retry_budget_exhausted_total = %v, want 2", got)
+	}
+}
+
+func TestRetryHonorsContext(t *testing.T) {
+	r := NewRetry("test-retry-context", WithBackoff(time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	err := r.Do(ctx, func() error {
+		calls++
+		return errRetryable
+	})
+	if !errors.Is(err, errRetryable) || calls != 1 {
+		t.Fatalf("err = %v after %d calls, want errRetryable after 1", err, calls)
+	}
+}
+
+func TestRetryUnsentOnly(t *testing.T) {
+	r := NewRetry("test-retry-unsent", WithBackoff(time.Millisecond, time.Millisecond))
+	refused := NewTransportError("test", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)})
+
+	for err, want := range map[error]int{
+		refused:      3,
+		errRetryable: 1,
+		NewTransportError("test", context.DeadlineExceeded): 1,
+	} {
+		calls := 0
+		_ = r.UnsentOnly().Do(context.Background(), func() error {
+			calls++
+			return err
+		})
+		if calls != want {
+			t.Fatalf("%v: %d calls, want %d", err, calls, want)
+		}
+	}
+}
+
+func TestRetryBackoffIsBounded(t *testing.T) {
+	r := NewRetry("test-retry-backoff", WithBackoff(10*time.Millisecond, 40*time.Millisecond))
+
+	for attempt, ceiling := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 40 * time.Millisecond, 60: 40 * time.Millisecond} {
+		for i := 0; i < 100; i++ {
+			if d := r.backoff(attempt); d < 0 || d >= ceiling {
+				t.Fatalf("backoff(%d) = %v, want in [0, %v)", attempt, d, ceiling)
+			}
+		}
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	record := func(name string) Policy {
+		return namedPolicy{name: name, before: func() { order = append(order, name) }}
+	}
+
+	err := Chain(record("outer"), record("inner")).Do(context.Background(), func() error {
+		order = append(order, "call")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(order); got != 3 || order[0] != "outer" || order[1] != "inner" || order[2] != "call" {
+		t.Fatalf("order = %v, want [outer inner call]", order)
+	}
+}
+
+// namedPolicy is a Policy that runs before ahead of every call
+type namedPolicy struct {
+	name   string
+	before func()
+}
+
+func (p namedPolicy) Name() string { return p.name }
+
+func (p namedPolicy) Do(ctx context.Context, fn func() error) error {
+	p.before()
+	return fn()
+}
@@ -0,0 +1,87 @@
+package resilience
+
+import "time"
+
+// defaultWindowBuckets is the number of buckets a sliding window is split into
+const defaultWindowBuckets = 10
+
+// windowBucket holds the call counts of one slice of a sliding window
+type windowBucket struct {
+	requests int
+	failures int
+	slow     int
+}
+
+// slidingWindow counts calls over a time window using a ring of fixed-width buckets
+// Counts expire one bucket at a time, so the window is accurate to one bucket width
+// Not safe for concurrent use; callers hold the owning policy's lock
+type slidingWindow struct {
+	buckets     []windowBucket
+	bucketWidth time.Duration
+	head        int       // index of the bucket receiving new calls
+	headStart   time.Time // start time of the head bucket
+}
+
+// newSlidingWindow creates a sliding window of the given length split into bucketCount buckets
+func newSlidingWindow(window time.Duration, bucketCount int) *slidingWindow {
+	if bucketCount < 1 {
+		bucketCount = 1
+	}
+	width := window / time.Duration(bucketCount)
+	if width <= 0 {
+		width = 1
+	}
+	return &slidingWindow{
+		buckets:     make([]windowBucket, bucketCount),
+		bucketWidth: width,
+		headStart:   time.Now(),
+	}
+}
+
+// record adds a call to the current bucket
+func (w *slidingWindow) record(now time.Time, failure, slow bool) {
+	w.advance(now)
+	w.buckets[w.head].requests++
+	if failure {
+		w.buckets[w.head].failures++
+	}
+	if slow {
+		w.buckets[w.head].slow++
+	}
+}
+
+// totals sums the calls of every bucket still inside the window
+func (w *slidingWindow) totals(now time.Time) windowBucket {
+	w.advance(now)
+	var sum windowBucket
+	for _, b := range w.buckets {
+		sum.requests += b.requests
+		sum.failures += b.failures
+		sum.slow += b.slow
+	}
+	return sum
+}
+
+// reset clears every bucket
+func (w *slidingWindow) reset(now time.Time) {
+	clear(w.buckets)
+	w.head = 0
+	w.headStart = now
+}
+
+// advance rotates the ring so the head bucket covers now, clearing expired buckets
+func (w *slidingWindow) advance(now time.Time) {
+	steps := int(now.Sub(w.headStart) / w.bucketWidth)
+	if steps <= 0 {
+		return
+	}
+	if steps >= len(w.buckets) {
+		w.reset(now)
+		return
+	}
+	for i := 0; i < steps; i++ {
+		w.head = (w.head + 1) % len(w.buckets)
+		w.buckets[w.head] = windowBucket{}
+	}
+	w.headStart = w.headStart.Add(time.Duration(steps) * w.bucketWidth)
+}
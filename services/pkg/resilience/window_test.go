@@ -0,0 +1,26 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingWindowExpiresBuckets(t *testing.T) {
+	start := time.Now()
+	w := newSlidingWindow(10*time.Second, 10)
+	w.reset(start)
+
+	w.record(start, true, false)
+	w.record(start.Add(3*time.Second), false, true)
+	w.record(start.Add(6*time.Second), true, false)
+
+	if got := w.totals(start.Add(9 * time.Second)); got != (windowBucket{requests: 3, failures: 2, slow: 1}) {
+		t.Fatalf("totals at 9s = %+v, want 3 requests / 2 failures / 1 slow", got)
+	}
+	if got := w.totals(start.Add(11 * time.Second)); got != (windowBucket{requests: 2, failures: 1, slow: 1}) {
+		t.Fatalf("totals at 11s = %+v, want 2 requests / 1 failure / 1 slow", got)
+	}
+	if got := w.totals(start.Add(30 * time.Second)); got != (windowBucket{}) {
+		t.Fatalf("totals at 30s = %+v, want empty", got)
+	}
+}